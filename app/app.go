@@ -2,34 +2,70 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
+	"unsafe"
 
+	"github.com/fspasovski/pocketstream-app/chat"
 	"github.com/fspasovski/pocketstream-app/common"
 	"github.com/fspasovski/pocketstream-app/config"
 	"github.com/fspasovski/pocketstream-app/input"
 	"github.com/fspasovski/pocketstream-app/model"
 	"github.com/fspasovski/pocketstream-app/pocketstream"
+	"github.com/fspasovski/pocketstream-app/provider"
+	"github.com/fspasovski/pocketstream-app/recorder"
+	"github.com/fspasovski/pocketstream-app/twitch"
+	"github.com/fspasovski/pocketstream-app/video"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 )
 
 type App struct {
-	Config              *config.Config
-	Running             bool
-	State               Screen
-	TopStreams          []model.Stream
-	Window              *sdl.Window
-	Renderer            *sdl.Renderer
-	Font                *ttf.Font
-	FooterFont          *ttf.Font
-	NeedsRedraw         bool
-	IsLoading           bool
-	LoadingText         string
-	UserDataManager     *UserDataManager
-	PocketstreamService *pocketstream.PocketstreamService
-	ImageDataService    *common.ImageDataService
+	Config               *config.Config
+	Running              bool
+	State                Screen
+	TopStreams           []model.Stream
+	IsLoadingMoreStreams bool
+	Window               *sdl.Window
+	Renderer             *sdl.Renderer
+	Font                 *ttf.Font
+	FooterFont           *ttf.Font
+	NeedsRedraw          bool
+	IsLoading            bool
+	LoadingText          string
+	UserDataManager      *UserDataManager
+	PocketstreamService  *pocketstream.PocketstreamService
+	ImageDataService     *common.ImageDataService
+	Chat                 *chat.Client
+	Provider             provider.StreamProvider
+	Recorder             *recorder.Recorder
+	PlaybackStats        string
+	NowPlaying           string
+	NowPlayingName       string
+	ToastText            string
+	ToastUntil           time.Time
+	OsdText              string
+	OsdLevel             int
+	OsdUntil             time.Time
+	// GoLive is the favorite stream announced by the toast, watched with Start while it shows
+	GoLive *model.Stream
+	// EmbeddedPlayer is set when the video is drawn in the app window rather than by an
+	// external player
+	EmbeddedPlayer bool
+	// Videos are the frame buffers of the embedded players, one per stream on screen
+	Videos      []*video.FrameBuffer
+	MultiView   []*model.Broadcaster
+	videoLayers map[*video.FrameBuffer]*videoLayer
+}
+
+// videoLayer is the texture a frame buffer is drawn from.
+type videoLayer struct {
+	texture  *sdl.Texture
+	rect     sdl.Rect
+	sequence uint64
 }
 
 func (a *App) LoadTopStreams() {
@@ -37,18 +73,87 @@ func (a *App) LoadTopStreams() {
 	a.LoadingText = "Loading streams..."
 
 	go func() {
-		topStreams, err := a.Config.TwitchService.GetTopStreams()
+		topStreams, err := a.Provider.GetTopStreams("")
 		if err != nil {
 			log.Println("Error fetching top streams:", err)
 			a.TopStreams = []model.Stream{}
 		} else {
-			a.TopStreams = topStreams
+			a.TopStreams = a.LoadStreamImages(topStreams)
 		}
 		a.IsLoading = false
 		a.NeedsRedraw = true
 	}()
 }
 
+// LoadMoreTopStreams fetches the page after the last loaded stream in the background
+// and appends it to the top streams.
+func (a *App) LoadMoreTopStreams() {
+	if a.IsLoadingMoreStreams || len(a.TopStreams) == 0 || a.TopStreams[len(a.TopStreams)-1].Cursor == "" {
+		return
+	}
+
+	a.IsLoadingMoreStreams = true
+	cursor := a.TopStreams[len(a.TopStreams)-1].Cursor
+
+	go func() {
+		nextPage, err := a.Provider.GetTopStreams(cursor)
+		if err != nil {
+			log.Println("Error fetching next page of top streams:", err)
+		} else {
+			a.TopStreams = model.AppendNewStreams(a.TopStreams, a.LoadStreamImages(nextPage))
+		}
+		a.IsLoadingMoreStreams = false
+	}()
+}
+
+// SwitchProvider moves on to the next configured stream provider and reloads the
+// top streams from it.
+func (a *App) SwitchProvider() {
+	for i, streamProvider := range a.Config.Providers {
+		if streamProvider == a.Provider {
+			a.Provider = a.Config.Providers[(i+1)%len(a.Config.Providers)]
+			break
+		}
+	}
+
+	a.TopStreams = make([]model.Stream, 0)
+	a.LoadTopStreams()
+}
+
+// LoadStreamImages fills in the preview and profile images of streams whose provider
+// did not already return them.
+func (a *App) LoadStreamImages(streams []model.Stream) []model.Stream {
+	imageUrls := make([]string, 0)
+	for _, stream := range streams {
+		if stream.PreviewImageData == nil && stream.PreviewImageURL != "" {
+			imageUrls = append(imageUrls, stream.PreviewImageURL)
+		}
+		if stream.Broadcaster.ProfileImageData == nil && stream.Broadcaster.ProfileImageURL != "" {
+			imageUrls = append(imageUrls, stream.Broadcaster.ProfileImageURL)
+		}
+	}
+
+	if len(imageUrls) == 0 {
+		return streams
+	}
+
+	imageData := a.ImageDataService.GetImageData(imageUrls)
+	result := make([]model.Stream, len(streams))
+	for i := 0; i < len(streams); i++ {
+		previewImageData := streams[i].PreviewImageData
+		if previewImageData == nil {
+			previewImageData = imageData[streams[i].PreviewImageURL]
+		}
+		profileImageData := streams[i].Broadcaster.ProfileImageData
+		if profileImageData == nil {
+			profileImageData = imageData[streams[i].Broadcaster.ProfileImageURL]
+		}
+		result[i] = streams[i].WithImageData(previewImageData, profileImageData)
+	}
+
+	return result
+}
+
 func (a *App) StartLoading(text string) {
 	a.IsLoading = true
 	a.LoadingText = text
@@ -59,7 +164,37 @@ func (a *App) FinishLoading() {
 	a.LoadingText = ""
 }
 
+func (a *App) StartChat(broadcasterLogin string) {
+	a.StopChat()
+
+	chatClient := chat.NewClient(chat.ClientConfig{
+		Endpoint:       a.Config.Chat.Endpoint,
+		MaxMessages:    a.Config.Chat.MaxMessages,
+		ConnectTimeout: a.Config.Chat.ConnectTimeout,
+		ReconnectDelay: a.Config.Chat.ReconnectDelay,
+		ReadTimeout:    a.Config.Chat.ReadTimeout,
+	})
+	if err := chatClient.Join(broadcasterLogin); err != nil {
+		log.Printf("Failed to join %s chat: %v", broadcasterLogin, err)
+		return
+	}
+	a.Chat = chatClient
+}
+
+func (a *App) StopChat() {
+	if a.Chat != nil {
+		a.Chat.Close()
+		a.Chat = nil
+	}
+}
+
 func (a *App) RaiseAppWindow() {
+	// The embedded player draws in the app window, which never loses focus
+	if a.EmbeddedPlayer {
+		a.NeedsRedraw = true
+		return
+	}
+
 	time.Sleep(200 * time.Millisecond)
 	a.Window.Hide()
 	time.Sleep(50 * time.Millisecond)
@@ -72,11 +207,173 @@ func (a *App) RaiseAppWindow() {
 	a.NeedsRedraw = true
 }
 
+// ShowToast shows a short message above the footer for a few seconds.
+func (a *App) ShowToast(text string) {
+	a.ToastText = text
+	a.ToastUntil = time.Now().Add(a.Config.UI.ToastUiConfig.Duration)
+	a.GoLive = nil
+}
+
+const (
+	// maxGoLiveTitleLength keeps go-live toasts on one line
+	maxGoLiveTitleLength = 40
+	goLiveHint           = " | Start: Watch"
+)
+
+// ShowGoLive announces a favorite that went live, with a shortcut to watch it for as
+// long as the toast shows on screens leaving Start free.
+func (a *App) ShowGoLive(stream model.Stream) {
+	name := stream.Broadcaster.DisplayName
+	if name == "" {
+		name = stream.Broadcaster.Login
+	}
+	title := []rune(stream.Title)
+	if len(title) > maxGoLiveTitleLength {
+		title = append(title[:maxGoLiveTitleLength-3], []rune("...")...)
+	}
+	a.ShowToast(name + " is live: " + string(title))
+	a.ToastUntil = time.Now().Add(2 * a.Config.UI.ToastUiConfig.Duration)
+	a.GoLive = &stream
+	a.NeedsRedraw = true
+}
+
+// GoLiveShortcut returns the stream announced by the visible toast, nil when there is none
+// or the current screen uses Start itself.
+func (a *App) GoLiveShortcut() *model.Stream {
+	if a.GoLive == nil || time.Now().After(a.ToastUntil) {
+		return nil
+	}
+	if binder, ok := a.State.(StartBinder); ok && binder.BindsStart() {
+		return nil
+	}
+	return a.GoLive
+}
+
+func (a *App) drawToast() {
+	if a.ToastText == "" {
+		return
+	}
+
+	if time.Now().After(a.ToastUntil) {
+		a.ToastText = ""
+		a.GoLive = nil
+		a.NeedsRedraw = true
+		return
+	}
+
+	// The shortcut is only offered where Start watches the stream
+	text := a.ToastText
+	if a.GoLiveShortcut() != nil {
+		text += goLiveHint
+	}
+
+	toastConfig := a.Config.UI.ToastUiConfig
+	w, h, err := a.Font.SizeUTF8(text)
+	if err != nil {
+		return
+	}
+
+	maxWidth := a.Config.Display.Width - 2*toastConfig.Padding
+	toast := sdl.Rect{
+		W: min(int32(w)+2*toastConfig.Padding, maxWidth),
+		H: int32(h) + 2*toastConfig.Padding,
+	}
+	toast.X = (a.Config.Display.Width - toast.W) / 2
+	toast.Y = a.Config.Display.Height - a.Config.UI.FooterHeight - toast.H - toastConfig.BottomMargin
+	if a.isNowPlayingVisible() {
+		toast.Y -= a.Config.UI.OverlayUiConfig.RowHeight
+	}
+
+	a.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	a.FillRect(&toast, a.Config.UI.Colors.OverlayBackgroundColor)
+	a.DrawRect(&toast, a.Config.UI.Colors.OverlayBorderColor)
+	a.Font.SetStyle(ttf.STYLE_NORMAL)
+	a.DrawCenteredTextInRect(text, &toast, a.Config.UI.Colors.OverlayTitleColor)
+}
+
+// ShowOsd shows the playback state in the middle of the screen for a moment, with the
+// level drawn as a bar when it is between 0 and 100.
+func (a *App) ShowOsd(text string, level int) {
+	a.OsdText = text
+	a.OsdLevel = level
+	a.OsdUntil = time.Now().Add(a.Config.UI.ToastUiConfig.Duration / 2)
+}
+
+func (a *App) drawOsd() {
+	if a.OsdText == "" {
+		return
+	}
+
+	if time.Now().After(a.OsdUntil) {
+		a.OsdText = ""
+		a.NeedsRedraw = true
+		return
+	}
+
+	overlayConfig := a.Config.UI.OverlayUiConfig
+	rows := int32(1)
+	if a.OsdLevel >= 0 {
+		rows++
+	}
+
+	panel := sdl.Rect{W: overlayConfig.Width / 2, H: rows*overlayConfig.RowHeight + 2*overlayConfig.Padding}
+	panel.X = (a.Config.Display.Width - panel.W) / 2
+	panel.Y = (a.Config.Display.Height - panel.H) / 2
+
+	a.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	a.FillRect(&panel, a.Config.UI.Colors.OverlayBackgroundColor)
+	a.DrawRect(&panel, a.Config.UI.Colors.OverlayBorderColor)
+
+	textRect := sdl.Rect{X: panel.X, Y: panel.Y + overlayConfig.Padding, W: panel.W, H: overlayConfig.RowHeight}
+	a.Font.SetStyle(ttf.STYLE_BOLD)
+	a.DrawCenteredTextInRect(a.OsdText, &textRect, a.Config.UI.Colors.OverlayTitleColor)
+	a.Font.SetStyle(ttf.STYLE_NORMAL)
+
+	if a.OsdLevel >= 0 {
+		bar := sdl.Rect{
+			X: panel.X + overlayConfig.Padding,
+			Y: textRect.Y + textRect.H + overlayConfig.RowHeight/4,
+			W: panel.W - 2*overlayConfig.Padding,
+			H: overlayConfig.RowHeight / 2,
+		}
+		a.DrawRect(&bar, a.Config.UI.Colors.OverlayItemTextColor)
+		level := bar
+		level.W = bar.W * int32(min(a.OsdLevel, 100)) / 100
+		a.FillRect(&level, a.Config.UI.Colors.SelectedOverlayItemColor)
+	}
+}
+
+// drawNowPlaying shows the stream playing in the background in a bar above the footer.
+func (a *App) drawNowPlaying() {
+	if !a.isNowPlayingVisible() {
+		return
+	}
+
+	rowHeight := a.Config.UI.OverlayUiConfig.RowHeight
+	panel := sdl.Rect{X: 0, Y: a.Config.Display.Height - a.Config.UI.FooterHeight - rowHeight, W: a.Config.Display.Width, H: rowHeight}
+
+	a.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	a.FillRect(&panel, a.Config.UI.Colors.OverlayBackgroundColor)
+	a.DrawLine(panel.X, panel.Y, panel.X+panel.W, panel.Y, a.Config.UI.Colors.OverlayBorderColor)
+	a.Font.SetStyle(ttf.STYLE_NORMAL)
+	a.DrawCenteredTextInRect(a.NowPlaying, &panel, a.Config.UI.Colors.OverlayTitleColor)
+}
+
+func (a *App) isNowPlayingVisible() bool {
+	return a.NowPlaying != "" && !a.IsLoading
+}
+
 type Screen interface {
 	HandleInput(appState *App, key input.Key)
 	Draw(appState *App)
 }
 
+// StartBinder is implemented by the screens with a use of their own for Start, which
+// the go-live shortcut then leaves to them.
+type StartBinder interface {
+	BindsStart() bool
+}
+
 func (a *App) ClearScreen() {
 	backgroundColor := a.Config.UI.Colors.BackgroundColor
 	a.Renderer.SetDrawColor(backgroundColor.R, backgroundColor.G, backgroundColor.B, backgroundColor.A)
@@ -91,24 +388,119 @@ func (a *App) Draw() {
 	a.State.Draw(a)
 	a.DrawHeader()
 	a.DrawFooter()
+	a.drawNowPlaying()
+	a.drawToast()
+	a.drawOsd()
 	a.Renderer.Present()
 	sdl.Delay(16)
 }
 
 func (a *App) redrawUI() {
+	a.NeedsRedraw = false
+
+	// Video frames are drawn every frame anyway, clearing would only flicker
+	if a.isVideoVisible() {
+		return
+	}
+
 	for i := 0; i < 3; i++ {
 		a.Renderer.SetDrawColor(0, 0, 0, 255)
 		a.Renderer.Clear()
 		a.Renderer.Present()
 		sdl.Delay(16) // One frame delay between clears
 	}
-
-	a.NeedsRedraw = false
 }
 
 func (a *App) DrawLoadingScreen() {
 	a.ClearScreen()
-	a.DrawCenteredText(a.LoadingText, a.Config.UI.Colors.LoadingTextColor)
+	rowHeight := a.Config.UI.OverlayUiConfig.RowHeight
+	statsRect := sdl.Rect{X: 0, Y: a.Config.Display.Height/2 + rowHeight, W: a.Config.Display.Width, H: rowHeight}
+
+	// The embedded player video takes the place of the loading text
+	if a.drawVideo() {
+		statsRect.Y = a.Config.Display.Height - a.Config.UI.FooterHeight - rowHeight
+	} else {
+		a.DrawCenteredText(a.LoadingText, a.Config.UI.Colors.LoadingTextColor)
+	}
+	a.DrawChatPanel()
+
+	if a.PlaybackStats != "" {
+		a.DrawCenteredTextInRect(a.PlaybackStats, &statsRect, a.Config.UI.Colors.LoadingTextColor)
+	}
+}
+
+// drawVideo draws the latest frames of the embedded players and reports whether there
+// was any to draw. Textures of players that are gone are released.
+func (a *App) drawVideo() bool {
+	if a.videoLayers == nil {
+		a.videoLayers = make(map[*video.FrameBuffer]*videoLayer)
+	}
+
+	drawn := false
+	current := make(map[*video.FrameBuffer]bool, len(a.Videos))
+	for _, frames := range a.Videos {
+		current[frames] = true
+		layer := a.videoLayers[frames]
+		if layer == nil {
+			layer = &videoLayer{}
+			a.videoLayers[frames] = layer
+		}
+
+		layer.sequence = frames.Update(layer.sequence, func(frame *video.Frame) {
+			a.updateVideoLayer(layer, frame)
+		})
+		if layer.sequence != 0 && layer.texture != nil {
+			a.Renderer.Copy(layer.texture, nil, &layer.rect)
+			drawn = true
+		}
+	}
+
+	for frames, layer := range a.videoLayers {
+		if !current[frames] {
+			if layer.texture != nil {
+				layer.texture.Destroy()
+			}
+			delete(a.videoLayers, frames)
+		}
+	}
+	return drawn
+}
+
+func (a *App) isVideoVisible() bool {
+	for _, layer := range a.videoLayers {
+		if layer.sequence != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// updateVideoLayer uploads a frame to the texture of the layer, which is recreated when
+// the frame size changes.
+func (a *App) updateVideoLayer(layer *videoLayer, frame *video.Frame) {
+	rect := sdl.Rect{X: int32(frame.X), Y: int32(frame.Y), W: int32(frame.Width), H: int32(frame.Height)}
+	if !frame.Positioned {
+		rect.X = (a.Config.Display.Width - rect.W) / 2
+		rect.Y = (a.Config.Display.Height - rect.H) / 2
+	}
+
+	if layer.texture == nil || rect.W != layer.rect.W || rect.H != layer.rect.H {
+		if layer.texture != nil {
+			layer.texture.Destroy()
+		}
+		texture, err := a.Renderer.CreateTexture(sdl.PIXELFORMAT_IYUV, sdl.TEXTUREACCESS_STREAMING, rect.W, rect.H)
+		if err != nil {
+			log.Printf("Failed to create video texture: %v", err)
+			layer.texture = nil
+			return
+		}
+		layer.texture = texture
+	}
+
+	layer.rect = rect
+	if err := layer.texture.Update(nil, unsafe.Pointer(&frame.Data[0]), frame.Width); err != nil {
+		log.Printf("Failed to update video texture: %v", err)
+	}
 }
 
 func (a *App) DrawCenteredText(text string, color sdl.Color) {
@@ -159,11 +551,15 @@ func (a *App) DrawCenteredTextInRect(text string, rect *sdl.Rect, color sdl.Colo
 }
 
 func (a *App) DrawText(text string, color sdl.Color, x int32, y int32) {
+	a.DrawTextWithFont(a.Font, text, color, x, y)
+}
+
+func (a *App) DrawTextWithFont(font *ttf.Font, text string, color sdl.Color, x int32, y int32) {
 	if text == "" {
 		return
 	}
 
-	surface, err := a.Font.RenderUTF8Blended(text, sdl.Color{R: color.R, G: color.G, B: color.B, A: color.A})
+	surface, err := font.RenderUTF8Blended(text, sdl.Color{R: color.R, G: color.G, B: color.B, A: color.A})
 	if err != nil {
 		return
 	}
@@ -184,9 +580,13 @@ func (a *App) DrawHeader() error {
 	headerBg := sdl.Rect{X: 0, Y: 0, W: a.Config.Display.Width, H: a.Config.UI.HeaderHeight}
 	a.FillRect(&headerBg, a.Config.UI.Colors.HeaderBackgroundColor)
 
-	// Draw app name (left side)
+	// Draw app name (left side), followed by the active provider when there is a choice
+	appName := a.Config.AppName
+	if len(a.Config.Providers) > 1 {
+		appName += " - " + a.Provider.Name()
+	}
 	a.Font.SetStyle(ttf.STYLE_BOLD)
-	nameSurface, err := a.Font.RenderUTF8Blended(a.Config.AppName, a.Config.UI.Colors.HeaderTextColor)
+	nameSurface, err := a.Font.RenderUTF8Blended(appName, a.Config.UI.Colors.HeaderTextColor)
 	if err != nil {
 		return err
 	}
@@ -232,9 +632,32 @@ func (a *App) DrawHeader() error {
 	versionDst := sdl.Rect{X: a.Config.Display.Width - vw - 20, Y: versionY, W: vw, H: vh}
 	a.Renderer.Copy(versionTexture, nil, &versionDst)
 
+	a.drawNowPlayingIndicator()
 	return nil
 }
 
+// drawNowPlayingIndicator shows a live dot and the name of the stream playing in the
+// background in the middle of the header, on every screen.
+func (a *App) drawNowPlayingIndicator() {
+	if a.NowPlayingName == "" {
+		return
+	}
+
+	w, h, err := a.Font.SizeUTF8(a.NowPlayingName)
+	if err != nil {
+		return
+	}
+
+	dotSize := int32(h) / 2
+	x := (a.Config.Display.Width - int32(w) - 2*dotSize) / 2
+	y := (a.Config.UI.HeaderHeight - int32(h)) / 2
+
+	dot := sdl.Rect{X: x, Y: (a.Config.UI.HeaderHeight - dotSize) / 2, W: dotSize, H: dotSize}
+	a.FillRect(&dot, a.Config.UI.Colors.StreamLiveBadgeBackgroundColor)
+	a.Font.SetStyle(ttf.STYLE_NORMAL)
+	a.DrawText(a.NowPlayingName, a.Config.UI.Colors.HeaderTextColor, x+2*dotSize, y)
+}
+
 func (a *App) DrawFooter() error {
 	footerY := a.Config.Display.Height - a.Config.UI.FooterHeight
 
@@ -244,7 +667,13 @@ func (a *App) DrawFooter() error {
 
 	// Draw button hint text
 	a.FooterFont.SetStyle(ttf.STYLE_NORMAL)
-	hintText := "Navigate: ↑↓ | →: Favorites | A: Select | B: Back | Y: Favorite | X: Search"
+	hintText := "Navigate: ↑↓ | ←: Categories | →: Favorites | A: Select | B: Back | Y: Favorite | X: Search"
+	if len(a.Config.Providers) > 1 {
+		hintText += " | Select: Provider"
+	}
+	if len(a.MultiView) > 0 {
+		hintText += fmt.Sprintf(" | R1: Multi-view (%d)", len(a.MultiView))
+	}
 
 	hintSurface, err := a.FooterFont.RenderUTF8Blended(hintText, a.Config.UI.Colors.FooterTextColor)
 	if err != nil {
@@ -296,10 +725,13 @@ func (a *App) DrawLine(x1, y1, x2, y2 int32, color sdl.Color) {
 type UserDataManager struct {
 	DataPath string
 	Data     UserData
+	// mu guards the favorites against the go-live notifier reading them in the background
+	mu sync.Mutex
 }
 
 type UserData struct {
 	FavoriteBroadcasters map[string]*model.Broadcaster
+	ShowChat             bool
 }
 
 func LoadUserDataManager() *UserDataManager {
@@ -328,15 +760,29 @@ func LoadUserDataManager() *UserDataManager {
 		userData.FavoriteBroadcasters = make(map[string]*model.Broadcaster, 0)
 	}
 
+	// Favorites saved before providers existed are keyed by login and belong to Twitch
+	favoriteBroadcasters := make(map[string]*model.Broadcaster, len(userData.FavoriteBroadcasters))
+	for _, broadcaster := range userData.FavoriteBroadcasters {
+		if broadcaster.Provider == "" {
+			broadcaster.Provider = twitch.ProviderName
+		}
+		favoriteBroadcasters[broadcaster.Key()] = broadcaster
+	}
+	userData.FavoriteBroadcasters = favoriteBroadcasters
+
 	userDataManager.Data = userData
 	return userDataManager
 }
 
 func (m *UserDataManager) ToggleFavoriteBroadcaster(broadcaster *model.Broadcaster) {
-	if m.Data.FavoriteBroadcasters[broadcaster.Login] != nil {
-		delete(m.Data.FavoriteBroadcasters, broadcaster.Login)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Data.FavoriteBroadcasters[broadcaster.Key()] != nil {
+		delete(m.Data.FavoriteBroadcasters, broadcaster.Key())
 	} else {
-		m.Data.FavoriteBroadcasters[broadcaster.Login] = &model.Broadcaster{
+		m.Data.FavoriteBroadcasters[broadcaster.Key()] = &model.Broadcaster{
+			Provider:        broadcaster.Provider,
 			Id:              broadcaster.Id,
 			Login:           broadcaster.Login,
 			DisplayName:     broadcaster.DisplayName,
@@ -364,16 +810,29 @@ func (m *UserDataManager) SaveData() {
 }
 
 func (m *UserDataManager) IsFavoriteBroadcaster(broadcaster *model.Broadcaster) bool {
-	return m.Data.FavoriteBroadcasters[broadcaster.Login] != nil
+	return m.Data.FavoriteBroadcasters[broadcaster.Key()] != nil
 }
 
-func (m *UserDataManager) GetBroadcasterImageUrl(login string) string {
-	if m.Data.FavoriteBroadcasters[login] != nil {
-		return m.Data.FavoriteBroadcasters[login].ProfileImageURL
+func (m *UserDataManager) GetBroadcasterImageUrl(broadcaster *model.Broadcaster) string {
+	if m.Data.FavoriteBroadcasters[broadcaster.Key()] != nil {
+		return m.Data.FavoriteBroadcasters[broadcaster.Key()].ProfileImageURL
 	}
 	return ""
 }
 
+func (m *UserDataManager) GetFavoriteBroadcasters(providerName string) []*model.Broadcaster {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	broadcasters := make([]*model.Broadcaster, 0)
+	for _, broadcaster := range m.Data.FavoriteBroadcasters {
+		if broadcaster.Provider == providerName {
+			broadcasters = append(broadcasters, broadcaster)
+		}
+	}
+	return broadcasters
+}
+
 func (m *UserDataManager) NoFavoriteBroadcasters() bool {
 	return len(m.Data.FavoriteBroadcasters) == 0
 }
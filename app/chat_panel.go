@@ -0,0 +1,102 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// DrawChatPanel draws the most recent chat messages on the right side of the screen,
+// newest at the bottom, next to the player window.
+func (a *App) DrawChatPanel() {
+	if a.Chat == nil {
+		return
+	}
+
+	padding := a.Config.Chat.PanelPadding
+	panel := sdl.Rect{
+		X: a.Config.Display.Width - a.Config.Chat.PanelWidth,
+		Y: a.Config.UI.HeaderHeight,
+		W: a.Config.Chat.PanelWidth,
+		H: a.Config.Display.Height - a.Config.UI.HeaderHeight - a.Config.UI.FooterHeight,
+	}
+	a.FillRect(&panel, a.Config.UI.Colors.ChatPanelBackgroundColor)
+
+	lineHeight := int32(a.FooterFont.Height())
+	maxWidth := panel.W - 2*padding
+	y := panel.Y + panel.H - padding
+
+	messages := a.Chat.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+		lines := a.WrapText(a.FooterFont, message.RenderedText(), maxWidth)
+
+		y -= lineHeight * int32(len(lines)+1)
+		if y < panel.Y+padding {
+			break
+		}
+
+		x := panel.X + padding
+		a.FooterFont.SetStyle(ttf.STYLE_BOLD)
+		if badges := message.BadgeText(); badges != "" {
+			a.DrawTextWithFont(a.FooterFont, badges, a.Config.UI.Colors.ChatBadgeColor, x, y)
+			badgesWidth, _, _ := a.FooterFont.SizeUTF8(badges + " ")
+			x += int32(badgesWidth)
+		}
+		a.DrawTextWithFont(a.FooterFont, message.DisplayName, a.chatNameColor(message.Color), x, y)
+		a.FooterFont.SetStyle(ttf.STYLE_NORMAL)
+
+		for j, line := range lines {
+			a.DrawTextWithFont(a.FooterFont, line, a.Config.UI.Colors.ChatTextColor, panel.X+padding, y+lineHeight*int32(j+1))
+		}
+	}
+}
+
+// WrapText splits the text into lines that fit into maxWidth, breaking words
+// that are longer than a whole line.
+func (a *App) WrapText(font *ttf.Font, text string, maxWidth int32) []string {
+	lines := make([]string, 0)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if width, _, err := font.SizeUTF8(candidate); err == nil && int32(width) <= maxWidth {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		line = ""
+		for _, r := range word {
+			if width, _, err := font.SizeUTF8(line + string(r)); err == nil && int32(width) > maxWidth && line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func (a *App) chatNameColor(hexColor string) sdl.Color {
+	value, err := strconv.ParseUint(strings.TrimPrefix(hexColor, "#"), 16, 32)
+	if len(hexColor) != 7 || err != nil {
+		return a.Config.UI.Colors.ChatDefaultNameColor
+	}
+
+	return sdl.Color{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 255}
+}
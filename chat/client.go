@@ -0,0 +1,196 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ClientConfig struct {
+	Endpoint       string
+	MaxMessages    int
+	ConnectTimeout time.Duration
+	ReconnectDelay time.Duration
+	// ReadTimeout drops a connection that received nothing for this long, zero keeps
+	// it open forever
+	ReadTimeout time.Duration
+}
+
+// Client is a read-only anonymous Twitch chat client speaking IRC over a websocket.
+type Client struct {
+	config   ClientConfig
+	channel  string
+	conn     *websocketConn
+	mu       sync.Mutex
+	messages []Message
+	closed   bool
+}
+
+func NewClient(config ClientConfig) *Client {
+	return &Client{config: config, messages: make([]Message, 0, config.MaxMessages)}
+}
+
+// Join connects to the configured endpoint and starts collecting the messages
+// of the given channel in the background until Close is called.
+func (c *Client) Join(channel string) error {
+	c.channel = strings.ToLower(channel)
+
+	conn, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	go c.readLoop(conn)
+	return nil
+}
+
+func (c *Client) connect() (*websocketConn, error) {
+	conn, err := dialWebsocket(c.config.Endpoint, c.config.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.readTimeout = c.config.ReadTimeout
+
+	commands := []string{
+		"CAP REQ :twitch.tv/tags twitch.tv/commands",
+		"PASS SCHMOOPIIE",
+		fmt.Sprintf("NICK justinfan%d", 10000+rand.Intn(89999)),
+		"JOIN #" + c.channel,
+	}
+	for _, command := range commands {
+		if err := conn.WriteText(command + "\r\n"); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *Client) readLoop(conn *websocketConn) {
+	for {
+		text, err := conn.ReadText()
+		if err != nil {
+			if c.isClosed() {
+				return
+			}
+
+			log.Printf("Chat connection for %s dropped: %v", c.channel, err)
+			conn.Close()
+			conn = c.reconnect()
+			if conn == nil {
+				return
+			}
+			continue
+		}
+
+		for _, row := range strings.Split(text, "\r\n") {
+			line := parseIrcLine(row)
+			if line == nil {
+				continue
+			}
+
+			switch line.Command {
+			case "PING":
+				conn.WriteText("PONG :" + strings.Join(line.Params, " ") + "\r\n")
+			case "RECONNECT":
+				conn.Close()
+			case "PRIVMSG":
+				if message := messageFromPrivmsg(line); message != nil {
+					c.addMessage(*message)
+				}
+			case "CLEARCHAT":
+				c.clearMessages(line)
+			}
+		}
+	}
+}
+
+func (c *Client) reconnect() *websocketConn {
+	for !c.isClosed() {
+		time.Sleep(c.config.ReconnectDelay)
+
+		conn, err := c.connect()
+		if err != nil {
+			log.Printf("Failed to reconnect to %s chat: %v", c.channel, err)
+			continue
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.closed {
+			conn.Close()
+			return nil
+		}
+		c.conn = conn
+		return conn
+	}
+	return nil
+}
+
+func (c *Client) addMessage(message Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.messages) >= c.config.MaxMessages {
+		c.messages = c.messages[1:]
+	}
+	c.messages = append(c.messages, message)
+}
+
+// clearMessages drops the messages of a timed out or banned user, or all of them
+// when the whole chat was cleared.
+func (c *Client) clearMessages(line *ircLine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(line.Params) < 2 {
+		c.messages = c.messages[:0]
+		return
+	}
+
+	kept := c.messages[:0]
+	for _, message := range c.messages {
+		if message.Login != line.Params[1] {
+			kept = append(kept, message)
+		}
+	}
+	c.messages = kept
+}
+
+// Messages returns a snapshot of the most recent messages, oldest first.
+func (c *Client) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := make([]Message, len(c.messages))
+	copy(messages, c.messages)
+	return messages
+}
+
+func (c *Client) Channel() string {
+	return c.channel
+}
+
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
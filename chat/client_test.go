@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serverConn is the server end of a websocket accepted by newChatServer
+type serverConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newChatServer starts a websocket server handing every accepted connection to the test
+func newChatServer(t *testing.T) (string, chan *serverConn) {
+	conns := make(chan *serverConn, 4)
+	var mu sync.Mutex
+	var hijacked []net.Conn
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(w, "not a websocket request", http.StatusBadRequest)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		hijacked = append(hijacked, conn)
+		mu.Unlock()
+
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGuid))
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+		rw.Flush()
+
+		conns <- &serverConn{conn: conn, reader: rw.Reader}
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		// Hijacked connections are not closed by the server
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range hijacked {
+			conn.Close()
+		}
+	})
+
+	return "ws" + strings.TrimPrefix(server.URL, "http"), conns
+}
+
+func (c *serverConn) writeLine(line string) {
+	c.conn.Write(serverFrame(true, opcodeText, []byte(line+"\r\n")))
+}
+
+// readLines reads the next count text frames sent by the client
+func (c *serverConn) readLines(t *testing.T, count int) []string {
+	t.Helper()
+
+	lines := make([]string, 0, count)
+	for range count {
+		frame := readClientFrame(c.reader)
+		checkClientFrame(t, frame, opcodeText)
+		lines = append(lines, strings.TrimSuffix(string(frame.payload), "\r\n"))
+	}
+	return lines
+}
+
+func acceptConn(t *testing.T, conns chan *serverConn) *serverConn {
+	t.Helper()
+
+	select {
+	case conn := <-conns:
+		conn.conn.SetDeadline(time.Now().Add(5 * time.Second))
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client did not connect")
+		return nil
+	}
+}
+
+// checkJoin reads the login commands of a new connection
+func checkJoin(t *testing.T, conn *serverConn) {
+	t.Helper()
+
+	lines := conn.readLines(t, 4)
+	if lines[0] != "CAP REQ :twitch.tv/tags twitch.tv/commands" || !strings.HasPrefix(lines[2], "NICK justinfan") || lines[3] != "JOIN #somechannel" {
+		t.Errorf("login commands = %q", lines)
+	}
+}
+
+// waitForMessages polls the client until it holds the wanted number of messages
+func waitForMessages(t *testing.T, client *Client, count int) []Message {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		messages := client.Messages()
+		if len(messages) == count {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client has %d messages, want %d", len(messages), count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient(t *testing.T) {
+	endpoint, conns := newChatServer(t)
+	client := NewClient(ClientConfig{Endpoint: endpoint, MaxMessages: 2, ConnectTimeout: time.Second, ReconnectDelay: 10 * time.Millisecond})
+	if err := client.Join("SomeChannel"); err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	defer client.Close()
+
+	conn := acceptConn(t, conns)
+	checkJoin(t, conn)
+
+	conn.writeLine("@display-name=Alice :alice!alice@alice.tmi.twitch.tv PRIVMSG #somechannel :first")
+	conn.writeLine("PING :tmi.twitch.tv")
+	if lines := conn.readLines(t, 1); lines[0] != "PONG :tmi.twitch.tv" {
+		t.Errorf("got %q, want the ping to be answered", lines[0])
+	}
+
+	conn.writeLine(":bob!bob@bob.tmi.twitch.tv PRIVMSG #somechannel :second\r\n:carol!carol@carol.tmi.twitch.tv PRIVMSG #somechannel :third")
+	messages := waitForMessages(t, client, 2)
+	if messages[0].Text != "second" || messages[1].Text != "third" {
+		t.Errorf("messages = %+v, want the two most recent", messages)
+	}
+
+	conn.writeLine(":tmi.twitch.tv CLEARCHAT #somechannel :bob")
+	messages = waitForMessages(t, client, 1)
+	if messages[0].Login != "carol" {
+		t.Errorf("messages = %+v, want only the ones of carol", messages)
+	}
+
+	conn.writeLine(":tmi.twitch.tv CLEARCHAT #somechannel")
+	waitForMessages(t, client, 0)
+}
+
+func TestClientReconnects(t *testing.T) {
+	tests := []struct {
+		name string
+		// drop ends the first connection
+		drop func(conn *serverConn)
+	}{
+		{"server asks to reconnect", func(conn *serverConn) { conn.writeLine(":tmi.twitch.tv RECONNECT") }},
+		{"connection is closed", func(conn *serverConn) { conn.conn.Close() }},
+		{"connection stays silent", func(conn *serverConn) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, conns := newChatServer(t)
+			client := NewClient(ClientConfig{
+				Endpoint:       endpoint,
+				MaxMessages:    10,
+				ConnectTimeout: time.Second,
+				ReconnectDelay: 10 * time.Millisecond,
+				ReadTimeout:    200 * time.Millisecond,
+			})
+			if err := client.Join("somechannel"); err != nil {
+				t.Fatalf("Join() error = %v", err)
+			}
+			defer client.Close()
+
+			first := acceptConn(t, conns)
+			checkJoin(t, first)
+			tt.drop(first)
+
+			second := acceptConn(t, conns)
+			checkJoin(t, second)
+			second.writeLine(":alice!alice@alice.tmi.twitch.tv PRIVMSG #somechannel :back")
+			if messages := waitForMessages(t, client, 1); messages[0].Text != "back" {
+				t.Errorf("messages = %+v, want the message of the new connection", messages)
+			}
+		})
+	}
+}
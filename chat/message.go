@@ -0,0 +1,227 @@
+package chat
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	Id          string
+	Channel     string
+	Login       string
+	DisplayName string
+	Color       string
+	Text        string
+	IsAction    bool
+	Badges      []Badge
+	Emotes      []Emote
+	SentAt      time.Time
+}
+
+type Badge struct {
+	Name    string
+	Version string
+}
+
+type Emote struct {
+	Id    string
+	Name  string
+	Start int
+	End   int
+}
+
+type ircLine struct {
+	Tags    map[string]string
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+var badgeLabels = map[string]string{
+	"broadcaster": "[STREAMER]",
+	"moderator":   "[MOD]",
+	"vip":         "[VIP]",
+	"subscriber":  "[SUB]",
+	"partner":     "[✓]",
+	"staff":       "[STAFF]",
+}
+
+// parseIrcLine splits a raw IRC line ("@tags :prefix COMMAND params :trailing")
+// into its parts, unescaping IRCv3 tag values.
+func parseIrcLine(line string) *ircLine {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil
+	}
+
+	parsed := &ircLine{Tags: make(map[string]string)}
+
+	if strings.HasPrefix(line, "@") {
+		tags, rest, _ := strings.Cut(line[1:], " ")
+		for _, tag := range strings.Split(tags, ";") {
+			key, value, _ := strings.Cut(tag, "=")
+			parsed.Tags[key] = unescapeTagValue(value)
+		}
+		line = rest
+	}
+
+	if strings.HasPrefix(line, ":") {
+		parsed.Prefix, line, _ = strings.Cut(line[1:], " ")
+	}
+
+	params, trailing, hasTrailing := strings.Cut(line, " :")
+
+	fields := strings.Fields(params)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	parsed.Command = fields[0]
+	parsed.Params = fields[1:]
+	if hasTrailing {
+		parsed.Params = append(parsed.Params, trailing)
+	}
+
+	return parsed
+}
+
+func unescapeTagValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			result.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 's':
+			result.WriteByte(' ')
+		case ':':
+			result.WriteByte(';')
+		case 'r':
+			result.WriteByte('\r')
+		case 'n':
+			result.WriteByte('\n')
+		default:
+			result.WriteByte(value[i])
+		}
+	}
+	return result.String()
+}
+
+func messageFromPrivmsg(line *ircLine) *Message {
+	if line.Command != "PRIVMSG" || len(line.Params) < 2 {
+		return nil
+	}
+
+	login, _, _ := strings.Cut(line.Prefix, "!")
+	text := line.Params[len(line.Params)-1]
+	message := &Message{
+		Id:          line.Tags["id"],
+		Channel:     strings.TrimPrefix(line.Params[0], "#"),
+		Login:       login,
+		DisplayName: line.Tags["display-name"],
+		Color:       line.Tags["color"],
+		Badges:      parseBadges(line.Tags["badges"]),
+		SentAt:      time.Now(),
+	}
+
+	if strings.HasPrefix(text, "\x01ACTION ") {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "\x01ACTION "), "\x01")
+		message.IsAction = true
+	}
+	message.Text = text
+	message.Emotes = parseEmotes(line.Tags["emotes"], text)
+
+	if sentAt, err := strconv.ParseInt(line.Tags["tmi-sent-ts"], 10, 64); err == nil {
+		message.SentAt = time.UnixMilli(sentAt)
+	}
+	if message.DisplayName == "" {
+		message.DisplayName = login
+	}
+
+	return message
+}
+
+func parseBadges(tag string) []Badge {
+	badges := make([]Badge, 0)
+	if tag == "" {
+		return badges
+	}
+
+	for _, badge := range strings.Split(tag, ",") {
+		name, version, _ := strings.Cut(badge, "/")
+		badges = append(badges, Badge{Name: name, Version: version})
+	}
+	return badges
+}
+
+// parseEmotes reads the "emotes" tag (id:start-end,start-end/id:start-end) where
+// positions are rune offsets into the message text.
+func parseEmotes(tag string, text string) []Emote {
+	emotes := make([]Emote, 0)
+	if tag == "" {
+		return emotes
+	}
+
+	runes := []rune(text)
+	for _, emote := range strings.Split(tag, "/") {
+		id, positions, found := strings.Cut(emote, ":")
+		if !found {
+			continue
+		}
+
+		for _, position := range strings.Split(positions, ",") {
+			startText, endText, _ := strings.Cut(position, "-")
+			start, startErr := strconv.Atoi(startText)
+			end, endErr := strconv.Atoi(endText)
+			if startErr != nil || endErr != nil || start < 0 || end >= len(runes) || start > end {
+				continue
+			}
+			emotes = append(emotes, Emote{Id: id, Name: string(runes[start : end+1]), Start: start, End: end})
+		}
+	}
+
+	sort.Slice(emotes, func(i, j int) bool { return emotes[i].Start < emotes[j].Start })
+	return emotes
+}
+
+// RenderedText returns the message text with emotes replaced by their :name: text form.
+func (m *Message) RenderedText() string {
+	if len(m.Emotes) == 0 {
+		return m.Text
+	}
+
+	runes := []rune(m.Text)
+	var result strings.Builder
+	position := 0
+	for _, emote := range m.Emotes {
+		if emote.Start < position {
+			continue
+		}
+		result.WriteString(string(runes[position:emote.Start]))
+		result.WriteString(":" + emote.Name + ":")
+		position = emote.End + 1
+	}
+	result.WriteString(string(runes[position:]))
+
+	return result.String()
+}
+
+// BadgeText returns the text labels of the badges that have one, e.g. "[MOD] [SUB]".
+func (m *Message) BadgeText() string {
+	labels := make([]string, 0, len(m.Badges))
+	for _, badge := range m.Badges {
+		if label, exists := badgeLabels[badge.Name]; exists {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, " ")
+}
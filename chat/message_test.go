@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseIrcLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want *ircLine
+	}{
+		{
+			name: "tags prefix and trailing",
+			line: "@badge-info=;color=#FF0000;display-name=Alice :alice!alice@alice.tmi.twitch.tv PRIVMSG #channel :hello there\r\n",
+			want: &ircLine{
+				Tags:    map[string]string{"badge-info": "", "color": "#FF0000", "display-name": "Alice"},
+				Prefix:  "alice!alice@alice.tmi.twitch.tv",
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "hello there"},
+			},
+		},
+		{
+			name: "escaped tag values",
+			line: `@system-msg=a\sb\:c\\d\ne;trailing=x\ :tmi.twitch.tv USERNOTICE #channel`,
+			want: &ircLine{
+				Tags:    map[string]string{"system-msg": "a b;c\\d\ne", "trailing": "x\\"},
+				Prefix:  "tmi.twitch.tv",
+				Command: "USERNOTICE",
+				Params:  []string{"#channel"},
+			},
+		},
+		{
+			name: "command only",
+			line: "PING :tmi.twitch.tv",
+			want: &ircLine{Tags: map[string]string{}, Command: "PING", Params: []string{"tmi.twitch.tv"}},
+		},
+		{
+			name: "trailing with colons",
+			line: ":tmi.twitch.tv CLEARCHAT #channel :bob",
+			want: &ircLine{Tags: map[string]string{}, Prefix: "tmi.twitch.tv", Command: "CLEARCHAT", Params: []string{"#channel", "bob"}},
+		},
+		{name: "empty", line: "\r\n", want: nil},
+		{name: "tags without a command", line: "@id=1 ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseIrcLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIrcLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageFromPrivmsg(t *testing.T) {
+	line := parseIrcLine("@badges=moderator/1,subscriber/12;color=#1E90FF;display-name=Bob;emotes=25:6-10,12-16/1902:0-4;id=abc;tmi-sent-ts=1714593600000 :bob!bob@bob.tmi.twitch.tv PRIVMSG #channel :Keepo Kappa Kappa")
+	message := messageFromPrivmsg(line)
+	if message == nil {
+		t.Fatal("messageFromPrivmsg() = nil")
+	}
+
+	want := &Message{
+		Id:          "abc",
+		Channel:     "channel",
+		Login:       "bob",
+		DisplayName: "Bob",
+		Color:       "#1E90FF",
+		Text:        "Keepo Kappa Kappa",
+		Badges:      []Badge{{Name: "moderator", Version: "1"}, {Name: "subscriber", Version: "12"}},
+		Emotes: []Emote{
+			{Id: "1902", Name: "Keepo", Start: 0, End: 4},
+			{Id: "25", Name: "Kappa", Start: 6, End: 10},
+			{Id: "25", Name: "Kappa", Start: 12, End: 16},
+		},
+		SentAt: time.UnixMilli(1714593600000),
+	}
+	if !reflect.DeepEqual(message, want) {
+		t.Errorf("messageFromPrivmsg() = %+v, want %+v", message, want)
+	}
+	if text := message.RenderedText(); text != ":Keepo: :Kappa: :Kappa:" {
+		t.Errorf("RenderedText() = %q", text)
+	}
+	if badges := message.BadgeText(); badges != "[MOD] [SUB]" {
+		t.Errorf("BadgeText() = %q", badges)
+	}
+}
+
+func TestMessageFromPrivmsgAction(t *testing.T) {
+	message := messageFromPrivmsg(parseIrcLine(":carol!carol@carol.tmi.twitch.tv PRIVMSG #channel :\x01ACTION waves 👋\x01"))
+	if message == nil {
+		t.Fatal("messageFromPrivmsg() = nil")
+	}
+	if !message.IsAction || message.Text != "waves 👋" {
+		t.Errorf("message = %+v, want the action text", message)
+	}
+	if message.DisplayName != "carol" {
+		t.Errorf("DisplayName = %q, want the login", message.DisplayName)
+	}
+}
+
+func TestMessageFromPrivmsgInvalid(t *testing.T) {
+	for _, line := range []string{
+		"PING :tmi.twitch.tv",
+		":alice!alice@alice.tmi.twitch.tv PRIVMSG #channel",
+	} {
+		if message := messageFromPrivmsg(parseIrcLine(line)); message != nil {
+			t.Errorf("messageFromPrivmsg(%q) = %+v, want nil", line, message)
+		}
+	}
+}
+
+func TestParseEmotes(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		text string
+		want []Emote
+	}{
+		{"no emotes", "", "hello", []Emote{}},
+		{"rune offsets", "25:2-6", "👋 Kappa", []Emote{{Id: "25", Name: "Kappa", Start: 2, End: 6}}},
+		{"out of range positions are skipped", "25:0-4,6-20/30:x-y", "Kappa hi", []Emote{{Id: "25", Name: "Kappa", Start: 0, End: 4}}},
+		{"missing positions are skipped", "25", "Kappa", []Emote{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseEmotes(tt.tag, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEmotes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
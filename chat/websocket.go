@@ -0,0 +1,241 @@
+package chat
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websocketGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opcodeContinuation = 0x0
+	opcodeText         = 0x1
+	opcodeBinary       = 0x2
+	opcodeClose        = 0x8
+	opcodePing         = 0x9
+	opcodePong         = 0xA
+
+	// maxPayloadSize bounds the frames and messages read, IRC lines being far shorter
+	maxPayloadSize = 64 * 1024
+	// maxControlPayloadSize is the largest payload RFC 6455 allows in a control frame
+	maxControlPayloadSize = 125
+)
+
+var errPayloadTooLarge = errors.New("websocket payload too large")
+
+// websocketConn is a minimal RFC 6455 client connection, enough to exchange the
+// text frames Twitch uses to carry IRC lines.
+type websocketConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+	// readTimeout is how long ReadText waits for a frame, zero waiting forever
+	readTimeout time.Duration
+}
+
+func dialWebsocket(endpoint string, timeout time.Duration) (*websocketConn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			address = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			address = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: u.Hostname()})
+	case "ws":
+		conn, err = dialer.Dial("tcp", address)
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	handshake := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+	if _, err := io.WriteString(conn, handshake); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: "GET"})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed with status %d", resp.StatusCode)
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGuid))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		conn.Close()
+		return nil, errors.New("websocket handshake returned an invalid accept key")
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &websocketConn{conn: conn, reader: reader}, nil
+}
+
+// ReadText returns the next complete text message, answering pings on the way.
+func (c *websocketConn) ReadText() (string, error) {
+	var message strings.Builder
+	for {
+		if c.readTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+
+		switch opcode {
+		case opcodePing:
+			if err := c.writeFrame(opcodePong, payload); err != nil {
+				return "", err
+			}
+		case opcodePong:
+		case opcodeClose:
+			c.writeFrame(opcodeClose, nil)
+			return "", io.EOF
+		case opcodeText, opcodeBinary, opcodeContinuation:
+			if message.Len()+len(payload) > maxPayloadSize {
+				return "", errPayloadTooLarge
+			}
+			message.Write(payload)
+			if fin {
+				return message.String(), nil
+			}
+		}
+	}
+}
+
+func (c *websocketConn) WriteText(text string) error {
+	return c.writeFrame(opcodeText, []byte(text))
+}
+
+func (c *websocketConn) Close() error {
+	c.writeFrame(opcodeClose, nil)
+	return c.conn.Close()
+}
+
+func (c *websocketConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	// The length comes from the server, check it before allocating the payload
+	if opcode&0x8 != 0 && (length > maxControlPayloadSize || !fin) {
+		return false, 0, nil, fmt.Errorf("invalid websocket control frame of %d bytes", length)
+	}
+	if length > maxPayloadSize {
+		return false, 0, nil, errPayloadTooLarge
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frame := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 0x80|126, byte(length>>8), byte(length))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	// Client frames must always be masked
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i := 0; i < length; i++ {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,212 @@
+package chat
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// newPipeConn returns a client connection and the server end of an in-memory pipe
+func newPipeConn(t *testing.T) (*websocketConn, net.Conn, *bufio.Reader) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return &websocketConn{conn: client, reader: bufio.NewReader(client)}, server, bufio.NewReader(server)
+}
+
+// serverFrame encodes an unmasked frame the way a server sends it
+func serverFrame(fin bool, opcode byte, payload []byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	return append(frame, payload...)
+}
+
+// clientFrame is a frame sent by the client, as read by the server
+type clientFrame struct {
+	fin     bool
+	masked  bool
+	opcode  byte
+	payload []byte
+	err     error
+}
+
+// readClientFrame decodes a frame sent by the client
+func readClientFrame(reader *bufio.Reader) clientFrame {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return clientFrame{err: err}
+	}
+	frame := clientFrame{fin: header[0]&0x80 != 0, masked: header[1]&0x80 != 0, opcode: header[0] & 0x0F}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, frame.err = io.ReadFull(reader, extended); frame.err != nil {
+			return frame
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, frame.err = io.ReadFull(reader, extended); frame.err != nil {
+			return frame
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	mask := make([]byte, 4)
+	if frame.masked {
+		if _, frame.err = io.ReadFull(reader, mask); frame.err != nil {
+			return frame
+		}
+	}
+	frame.payload = make([]byte, length)
+	if _, frame.err = io.ReadFull(reader, frame.payload); frame.err != nil {
+		return frame
+	}
+	for i := range frame.payload {
+		frame.payload[i] ^= mask[i%4]
+	}
+	return frame
+}
+
+// checkClientFrame fails the test unless frame is a complete masked frame of the given opcode
+func checkClientFrame(t *testing.T, frame clientFrame, opcode byte) {
+	t.Helper()
+
+	if frame.err != nil {
+		t.Fatalf("reading the client frame: %v", frame.err)
+	}
+	if !frame.fin || !frame.masked {
+		t.Errorf("client frame fin = %v and masked = %v, want both", frame.fin, frame.masked)
+	}
+	if frame.opcode != opcode {
+		t.Errorf("client frame opcode = %d, want %d", frame.opcode, opcode)
+	}
+}
+
+func TestWebsocketWriteText(t *testing.T) {
+	for _, length := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		conn, server, reader := newPipeConn(t)
+		text := strings.Repeat("a", length)
+
+		errs := make(chan error, 1)
+		go func() { errs <- conn.WriteText(text) }()
+
+		frame := readClientFrame(reader)
+		if err := <-errs; err != nil {
+			t.Fatalf("WriteText() error = %v", err)
+		}
+		checkClientFrame(t, frame, opcodeText)
+		if string(frame.payload) != text {
+			t.Errorf("got %d bytes, want the %d bytes written", len(frame.payload), length)
+		}
+		server.Close()
+	}
+}
+
+func TestWebsocketReadText(t *testing.T) {
+	conn, server, reader := newPipeConn(t)
+
+	go func() {
+		server.Write(serverFrame(false, opcodeText, []byte("PRIVMSG #channel ")))
+		server.Write(serverFrame(true, opcodePing, []byte("ping")))
+		server.Write(serverFrame(true, opcodeContinuation, []byte(":hello")))
+	}()
+
+	pongs := make(chan clientFrame, 1)
+	go func() { pongs <- readClientFrame(reader) }()
+
+	text, err := conn.ReadText()
+	if err != nil {
+		t.Fatalf("ReadText() error = %v", err)
+	}
+	if text != "PRIVMSG #channel :hello" {
+		t.Errorf("ReadText() = %q, want the joined fragments", text)
+	}
+	pong := <-pongs
+	checkClientFrame(t, pong, opcodePong)
+	if string(pong.payload) != "ping" {
+		t.Errorf("pong payload = %q, want the ping payload", pong.payload)
+	}
+}
+
+func TestWebsocketReadTextClose(t *testing.T) {
+	conn, server, reader := newPipeConn(t)
+
+	go server.Write(serverFrame(true, opcodeClose, nil))
+	closes := make(chan clientFrame, 1)
+	go func() { closes <- readClientFrame(reader) }()
+
+	if _, err := conn.ReadText(); err != io.EOF {
+		t.Errorf("ReadText() error = %v, want EOF", err)
+	}
+	checkClientFrame(t, <-closes, opcodeClose)
+}
+
+func TestWebsocketReadTextRejectsFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+	}{
+		{
+			name:   "oversized frame",
+			frames: [][]byte{{0x81, 127, 0, 0, 0, 0, 0x7F, 0xFF, 0xFF, 0xFF}},
+		},
+		{
+			name:   "oversized control frame",
+			frames: [][]byte{serverFrame(true, opcodePing, make([]byte, 126))},
+		},
+		{
+			name:   "fragmented control frame",
+			frames: [][]byte{serverFrame(false, opcodePing, []byte("ping"))},
+		},
+		{
+			name: "oversized message",
+			frames: [][]byte{
+				serverFrame(false, opcodeText, make([]byte, maxPayloadSize/2+1)),
+				serverFrame(true, opcodeContinuation, make([]byte, maxPayloadSize/2+1)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, server, _ := newPipeConn(t)
+			go func() {
+				for _, frame := range tt.frames {
+					if _, err := server.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+
+			_, err := conn.ReadText()
+			if err == nil {
+				t.Fatal("ReadText() error = nil, want the frame to be rejected")
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) {
+				t.Errorf("ReadText() error = %v, want the frame to be rejected", err)
+			}
+		})
+	}
+}
@@ -2,18 +2,29 @@ package config
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/fspasovski/pocketstream-app/iptv"
+	"github.com/fspasovski/pocketstream-app/provider"
 	"github.com/fspasovski/pocketstream-app/twitch"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+const defaultIptvPlaylist = "playlist.m3u"
+
 type Config struct {
 	AppName            string
 	AppVersion         string
 	Display            DisplayConfig
-	TwitchService      *twitch.TwitchService
+	Providers          []provider.StreamProvider
 	UI                 UIConfig
 	Player             PlayerConfig
+	Chat               ChatConfig
+	Recorder           RecorderConfig
+	HlsProxy           HlsProxyConfig
+	Notifier           NotifierConfig
 	PocketstreamApiUrl string
 }
 
@@ -49,6 +60,18 @@ type Colors struct {
 	StreamTitleColor               sdl.Color
 	SelectedStreamBorderColor      sdl.Color
 	FavoriteIconColor              sdl.Color
+	OverlayBackgroundColor         sdl.Color
+	OverlayBorderColor             sdl.Color
+	OverlayTitleColor              sdl.Color
+	OverlayItemTextColor           sdl.Color
+	SelectedOverlayItemColor       sdl.Color
+	SelectedOverlayItemTextColor   sdl.Color
+	ChatPanelBackgroundColor       sdl.Color
+	ChatTextColor                  sdl.Color
+	ChatBadgeColor                 sdl.Color
+	ChatDefaultNameColor           sdl.Color
+	// OfflineOverlayColor greys out the thumbnails of offline channels
+	OfflineOverlayColor sdl.Color
 }
 
 type StreamsUiConfig struct {
@@ -73,6 +96,19 @@ type StreamsUiConfig struct {
 	FavoriteIconRightMargin int32
 }
 
+type OverlayUiConfig struct {
+	Width           int32
+	RowHeight       int32
+	Padding         int32
+	MaxVisibleItems int
+}
+
+type ToastUiConfig struct {
+	Duration     time.Duration
+	Padding      int32
+	BottomMargin int32
+}
+
 type UIConfig struct {
 	HeaderHeight      int32
 	FooterHeight      int32
@@ -95,11 +131,75 @@ type UIConfig struct {
 	VirtualTopPadding int32
 	Colors            Colors
 	StreamsUiConfig   StreamsUiConfig
+	OverlayUiConfig   OverlayUiConfig
+	ToastUiConfig     ToastUiConfig
+}
+
+type ChatConfig struct {
+	Endpoint       string
+	MaxMessages    int
+	ConnectTimeout time.Duration
+	ReconnectDelay time.Duration
+	PanelWidth     int32
+	PanelPadding   int32
+	// ReadTimeout is how long the chat may stay silent before reconnecting, Twitch
+	// sends a PING about every five minutes
+	ReadTimeout time.Duration
+}
+
+type HlsProxyConfig struct {
+	Enabled bool
+	// BufferSegments is the number of downloaded segments kept for the player
+	BufferSegments int
+	// LiveEdgeSegments is the number of segments before the live edge playback starts at
+	LiveEdgeSegments int
+	Retries          int
+	RetryDelay       time.Duration
+	// SkipAds holds back stitched ad segments from the player
+	SkipAds bool
+	// SwitchVariantOnAds falls back to another variant without ads during an ad break
+	SwitchVariantOnAds bool
+	// AdWaitTimeout is how long an ad break at the start of a stream is waited out
+	AdWaitTimeout time.Duration
+	HttpClient    *http.Client
+}
+
+type RecorderConfig struct {
+	Directory string
+	// MinFreeBytes is the free disk space below which recordings are refused or stopped
+	MinFreeBytes      uint64
+	DiskCheckInterval time.Duration
+}
+
+type NotifierConfig struct {
+	// PollInterval is how often the favorites are checked for going live, 0 turns the
+	// go-live notifications off
+	PollInterval time.Duration
+	// StatePath is the file keeping which favorites were live, so they are not announced
+	// again after a restart
+	StatePath string
 }
 
 type PlayerConfig struct {
-	StreamWidth  int
-	StreamHeight int
+	// Backend is the player, either "embedded", "ffplay" or "mpv"
+	Backend          string
+	MpvIpcSocketPath string
+	// AudioOutput and AudioDevice are the ffmpeg output the embedded player plays audio on
+	AudioOutput              string
+	AudioDevice              string
+	StreamWidth              int
+	StreamHeight             int
+	StreamQualityPreferences []string
+	ReconnectAttempts        int
+	// ReconnectDelay is the wait before the first reconnect, it doubles up to MaxReconnectDelay
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	// StallTimeout is how long the player may buffer before the stream is considered dropped
+	StallTimeout time.Duration
+	// StreamCacheTtl is how long stream urls without an expiry of their own are reused
+	StreamCacheTtl time.Duration
+	// StreamRefreshAhead is how long before expiring a cached stream url gets refreshed
+	StreamRefreshAhead time.Duration
 }
 
 func Load(screenWidth, screenHeight int) *Config {
@@ -110,7 +210,7 @@ func Load(screenWidth, screenHeight int) *Config {
 	inputBoxTopMargin := headerHeight + 50
 	inputBoxHeight := int32(float32(screenHeight) * 0.075)
 
-	return &Config{
+	cfg := &Config{
 		AppName:            "Pocketstream",
 		AppVersion:         "v1.1.0",
 		PocketstreamApiUrl: "https://pocketstream.app/api",
@@ -118,16 +218,28 @@ func Load(screenWidth, screenHeight int) *Config {
 			Width:  int32(screenWidth),
 			Height: int32(screenHeight),
 		},
-		TwitchService: &twitch.TwitchService{
-			Config: twitch.TwitchConfig{
-				ClientId:               "kimne78kx3ncx6brgo4mv6wki5h1ko",
-				GqlUrl:                 "https://gql.twitch.tv/gql",
-				UsherUrl:               "https://usher.ttvnw.net/api/channel/hls",
-				TopStreamsLimit:        10,
-				HttpClient:             &http.Client{},
-				StreamResolution:       "RESOLUTION=852x480",
-				BrowsPagePopularSha256: "75a4899f0a765cc08576125512f710e157b147897c06f96325de72d4c5a64890",
-				SearchResultsSha256:    "845698a3efbde3c2d1cc31e77ca1160cde6a21c556ad808106910ff63e727b98",
+		Providers: []provider.StreamProvider{
+			&twitch.TwitchService{
+				Config: twitch.TwitchConfig{
+					ClientId:                  "kimne78kx3ncx6brgo4mv6wki5h1ko",
+					GqlUrl:                    "https://gql.twitch.tv/gql",
+					UsherUrl:                  "https://usher.ttvnw.net/api/channel/hls",
+					VodUsherUrl:               "https://usher.ttvnw.net/vod",
+					TopStreamsLimit:           10,
+					TopCategoriesLimit:        30,
+					CategoryStreamsLimit:      10,
+					VideosLimit:               30,
+					ClipsLimit:                20,
+					BoxArtWidth:               int(float32(screenHeight)*0.24) * 3 / 4,
+					BoxArtHeight:              int(float32(screenHeight) * 0.24),
+					PreviewImageWidth:         int(thumbnailWidth),
+					PreviewImageHeight:        int(float32(screenHeight) * 0.24),
+					DetailsPreviewImageWidth:  screenWidth / 2,
+					DetailsPreviewImageHeight: screenWidth / 2 * 9 / 16,
+					HttpClient:                &http.Client{},
+					BrowsPagePopularSha256:    "75a4899f0a765cc08576125512f710e157b147897c06f96325de72d4c5a64890",
+					SearchResultsSha256:       "845698a3efbde3c2d1cc31e77ca1160cde6a21c556ad808106910ff63e727b98",
+				},
 			},
 		},
 		UI: UIConfig{
@@ -152,6 +264,17 @@ func Load(screenWidth, screenHeight int) *Config {
 				FavoriteIconTopMargin:   favoriteIconSize + 20,
 				FavoriteIconRightMargin: favoriteIconSize + 35,
 			},
+			OverlayUiConfig: OverlayUiConfig{
+				Width:           int32(float32(screenWidth) * 0.6),
+				RowHeight:       int32(float32(screenHeight) * 0.065),
+				Padding:         15,
+				MaxVisibleItems: 7,
+			},
+			ToastUiConfig: ToastUiConfig{
+				Duration:     4 * time.Second,
+				Padding:      10,
+				BottomMargin: 10,
+			},
 			HeaderHeight:      int32(float32(screenHeight) * 0.104),
 			FooterHeight:      int32(float32(screenHeight) * 0.083),
 			RowHeight:         130,
@@ -198,11 +321,126 @@ func Load(screenWidth, screenHeight int) *Config {
 				StreamTitleColor:               sdl.Color{148, 163, 184, 255},
 				SelectedStreamBorderColor:      sdl.Color{59, 130, 246, 255},
 				FavoriteIconColor:              sdl.Color{R: 255, G: 215, B: 0, A: 255},
+				OverlayBackgroundColor:         sdl.Color{R: 30, G: 41, B: 59, A: 240},
+				OverlayBorderColor:             sdl.Color{R: 59, G: 130, B: 246, A: 255},
+				OverlayTitleColor:              sdl.Color{R: 240, G: 249, B: 255, A: 255},
+				OverlayItemTextColor:           sdl.Color{R: 148, G: 163, B: 184, A: 255},
+				SelectedOverlayItemColor:       sdl.Color{R: 59, G: 130, B: 246, A: 255},
+				SelectedOverlayItemTextColor:   sdl.Color{R: 255, G: 255, B: 255, A: 255},
+				ChatPanelBackgroundColor:       sdl.Color{R: 30, G: 41, B: 59, A: 255},
+				ChatTextColor:                  sdl.Color{R: 226, G: 232, B: 240, A: 255},
+				ChatBadgeColor:                 sdl.Color{R: 250, G: 204, B: 21, A: 255},
+				ChatDefaultNameColor:           sdl.Color{R: 59, G: 130, B: 246, A: 255},
+				OfflineOverlayColor:            sdl.Color{R: 15, G: 23, B: 42, A: 170},
 			},
 		},
+		Chat: ChatConfig{
+			Endpoint:       "wss://irc-ws.chat.twitch.tv:443",
+			MaxMessages:    50,
+			ConnectTimeout: 10 * time.Second,
+			ReconnectDelay: 3 * time.Second,
+			ReadTimeout:    6 * time.Minute,
+			PanelWidth:     int32(float32(screenWidth) * 0.3),
+			PanelPadding:   8,
+		},
+		HlsProxy: HlsProxyConfig{
+			Enabled:            true,
+			BufferSegments:     15,
+			LiveEdgeSegments:   3,
+			Retries:            3,
+			RetryDelay:         500 * time.Millisecond,
+			SkipAds:            true,
+			SwitchVariantOnAds: true,
+			AdWaitTimeout:      3 * time.Minute,
+			HttpClient:         &http.Client{Timeout: 10 * time.Second},
+		},
+		Recorder: RecorderConfig{
+			Directory:         recordingsDirectory(),
+			MinFreeBytes:      512 * 1024 * 1024,
+			DiskCheckInterval: 10 * time.Second,
+		},
+		Notifier: NotifierConfig{
+			PollInterval: goLivePollInterval(),
+			StatePath:    "./liveState.json",
+		},
 		Player: PlayerConfig{
-			StreamWidth:  screenWidth,
-			StreamHeight: screenHeight,
+			Backend:                  playerBackend(),
+			MpvIpcSocketPath:         filepath.Join(os.TempDir(), "pocketstream-mpv.sock"),
+			AudioOutput:              "alsa",
+			AudioDevice:              "default",
+			StreamWidth:              screenWidth,
+			StreamHeight:             screenHeight,
+			StreamQualityPreferences: []string{"480p", "360p", "720p", "audio_only"},
+			ReconnectAttempts:        5,
+			ReconnectDelay:           time.Second,
+			MaxReconnectDelay:        16 * time.Second,
+			StallTimeout:             10 * time.Second,
+			StreamCacheTtl:           10 * time.Minute,
+			StreamRefreshAhead:       2 * time.Minute,
 		},
 	}
+
+	if playlistSource := iptvPlaylistSource(); playlistSource != "" {
+		cfg.Providers = append(cfg.Providers, &iptv.IptvService{
+			Config: iptv.IptvConfig{
+				PlaylistSource: playlistSource,
+				PlaylistTtl:    5 * time.Minute,
+				PageSize:       10,
+				HttpClient:     &http.Client{},
+			},
+		})
+	}
+
+	return cfg
+}
+
+// playerBackend returns the player set in the POCKETSTREAM_PLAYER environment variable,
+// ffplay by default.
+func playerBackend() string {
+	if backend := os.Getenv("POCKETSTREAM_PLAYER"); backend != "" {
+		return backend
+	}
+	return "ffplay"
+}
+
+// goLivePollInterval returns the interval set in the POCKETSTREAM_GO_LIVE_INTERVAL
+// environment variable, e.g. "5m" or "0" to turn the notifications off, 2 minutes by
+// default.
+func goLivePollInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("POCKETSTREAM_GO_LIVE_INTERVAL")); err == nil && interval >= 0 {
+		return interval
+	}
+	return 2 * time.Minute
+}
+
+// recordingsDirectory returns the directory set in the POCKETSTREAM_RECORDINGS_DIR
+// environment variable, ./recordings by default.
+func recordingsDirectory() string {
+	if directory := os.Getenv("POCKETSTREAM_RECORDINGS_DIR"); directory != "" {
+		return directory
+	}
+	return "./recordings"
+}
+
+// iptvPlaylistSource returns the M3U playlist set in the POCKETSTREAM_IPTV_PLAYLIST
+// environment variable, falling back to a playlist.m3u next to the app.
+func iptvPlaylistSource() string {
+	if source := os.Getenv("POCKETSTREAM_IPTV_PLAYLIST"); source != "" {
+		return source
+	}
+	if _, err := os.Stat(defaultIptvPlaylist); err == nil {
+		return defaultIptvPlaylist
+	}
+	return ""
+}
+
+// Provider returns the configured stream provider with the given name, and false when
+// no provider has that name, e.g. for favorites of a provider no longer configured.
+func (c *Config) Provider(name string) (provider.StreamProvider, bool) {
+	for _, streamProvider := range c.Providers {
+		if streamProvider.Name() == name {
+			return streamProvider, true
+		}
+	}
+	return nil, false
 }
@@ -0,0 +1,190 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	streamInfTag = "#EXT-X-STREAM-INF:"
+	mediaTag     = "#EXT-X-MEDIA:"
+
+	AudioOnlyGroupId = "audio_only"
+	SourceGroupId    = "chunked"
+)
+
+type MasterPlaylist struct {
+	Variants []Variant
+	// ExpiresAt is when the signed variant urls stop working, zero when unknown
+	ExpiresAt time.Time
+}
+
+type Variant struct {
+	Name        string
+	GroupId     string
+	Url         string
+	Bandwidth   int
+	Resolution  string
+	Width       int
+	Height      int
+	FrameRate   float64
+	Codecs      string
+	IsSource    bool
+	IsAudioOnly bool
+}
+
+type media struct {
+	groupId string
+	name    string
+}
+
+// ParseMasterPlaylist reads every #EXT-X-STREAM-INF entry of an HLS master playlist
+// and resolves its rendition name from the matching #EXT-X-MEDIA group.
+func ParseMasterPlaylist(content string) (*MasterPlaylist, error) {
+	rows := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(rows) == 0 || strings.TrimSpace(rows[0]) != "#EXTM3U" {
+		return nil, errors.New("not an HLS playlist")
+	}
+
+	medias := make(map[string]media)
+	playlist := &MasterPlaylist{Variants: make([]Variant, 0)}
+
+	for i := 0; i < len(rows); i++ {
+		row := strings.TrimSpace(rows[i])
+
+		if strings.HasPrefix(row, mediaTag) {
+			attributes := ParseAttributeList(strings.TrimPrefix(row, mediaTag))
+			if attributes["TYPE"] == "VIDEO" || attributes["TYPE"] == "AUDIO" {
+				medias[attributes["GROUP-ID"]] = media{groupId: attributes["GROUP-ID"], name: attributes["NAME"]}
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(row, streamInfTag) {
+			continue
+		}
+
+		variant := parseVariant(ParseAttributeList(strings.TrimPrefix(row, streamInfTag)), medias)
+		for i+1 < len(rows) {
+			i++
+			uri := strings.TrimSpace(rows[i])
+			if uri != "" && !strings.HasPrefix(uri, "#") {
+				variant.Url = uri
+				break
+			}
+		}
+
+		if variant.Url == "" {
+			return nil, fmt.Errorf("variant %s has no uri", variant.Name)
+		}
+		playlist.Variants = append(playlist.Variants, variant)
+	}
+
+	if len(playlist.Variants) == 0 {
+		return nil, errors.New("playlist has no variants")
+	}
+
+	return playlist, nil
+}
+
+func parseVariant(attributes map[string]string, medias map[string]media) Variant {
+	variant := Variant{
+		Resolution: attributes["RESOLUTION"],
+		Codecs:     attributes["CODECS"],
+		GroupId:    attributes["VIDEO"],
+	}
+
+	variant.Bandwidth, _ = strconv.Atoi(attributes["BANDWIDTH"])
+	variant.FrameRate, _ = strconv.ParseFloat(attributes["FRAME-RATE"], 64)
+
+	if width, height, found := strings.Cut(variant.Resolution, "x"); found {
+		variant.Width, _ = strconv.Atoi(width)
+		variant.Height, _ = strconv.Atoi(height)
+	}
+
+	if variant.GroupId == "" {
+		variant.GroupId = attributes["AUDIO"]
+	}
+
+	if m, exists := medias[variant.GroupId]; exists && m.name != "" {
+		variant.Name = m.name
+	} else if attributes["IVS-NAME"] != "" {
+		variant.Name = attributes["IVS-NAME"]
+	} else if variant.Height > 0 {
+		variant.Name = fmt.Sprintf("%dp", variant.Height)
+		if variant.FrameRate > 30.5 {
+			variant.Name += strconv.Itoa(int(variant.FrameRate + 0.5))
+		}
+	} else {
+		variant.Name = AudioOnlyGroupId
+	}
+
+	variant.IsAudioOnly = variant.GroupId == AudioOnlyGroupId || variant.Name == AudioOnlyGroupId || variant.Height == 0
+	variant.IsSource = variant.GroupId == SourceGroupId || strings.Contains(variant.Name, "(source)")
+
+	return variant
+}
+
+// ResolveReferences turns relative variant uris into absolute urls against the
+// url the playlist was downloaded from.
+func (p *MasterPlaylist) ResolveReferences(playlistUrl string) error {
+	base, err := url.Parse(playlistUrl)
+	if err != nil {
+		return err
+	}
+
+	for i := range p.Variants {
+		reference, err := url.Parse(p.Variants[i].Url)
+		if err != nil {
+			return err
+		}
+		p.Variants[i].Url = base.ResolveReference(reference).String()
+	}
+	return nil
+}
+
+// Quality returns the short rendition name, e.g. "720p60" or "audio_only".
+func (v *Variant) Quality() string {
+	return strings.TrimSpace(strings.TrimSuffix(v.Name, "(source)"))
+}
+
+func (v *Variant) Label() string {
+	label := v.Quality()
+	if v.IsSource {
+		label += " (source)"
+	}
+	if v.Bandwidth > 0 {
+		label += fmt.Sprintf(" - %.1f Mbps", float64(v.Bandwidth)/1000000.0)
+	}
+	return label
+}
+
+// ParseAttributeList splits an HLS attribute list (KEY=VALUE,KEY="VALUE,WITH,COMMAS")
+// into a map with the surrounding quotes removed.
+func ParseAttributeList(list string) map[string]string {
+	attributes := make(map[string]string)
+
+	inQuotes := false
+	start := 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) && list[i] == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if i < len(list) && (list[i] != ',' || inQuotes) {
+			continue
+		}
+
+		key, value, found := strings.Cut(list[start:i], "=")
+		if found {
+			attributes[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), "\"")
+		}
+		start = i + 1
+	}
+
+	return attributes
+}
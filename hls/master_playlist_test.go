@@ -0,0 +1,111 @@
+package hls
+
+import "testing"
+
+func TestParseMasterPlaylist(t *testing.T) {
+	content := `#EXTM3U
+#EXT-X-TWITCH-INFO:NODE="video-edge",SERVER-TIME="1714593600.00"
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="chunked",NAME="1080p60 (source)",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:BANDWIDTH=6000000,RESOLUTION=1920x1080,CODECS="avc1.64002A,mp4a.40.2",VIDEO="chunked",FRAME-RATE=60.000
+https://video.example.com/chunked/index.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="720p30",NAME="720p",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720,CODECS="avc1.4D401F,mp4a.40.2",VIDEO="720p30",FRAME-RATE=30.000
+
+720p30/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1200000,RESOLUTION=852x480,FRAME-RATE=60.000
+480p60/index.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="audio_only",NAME="audio_only",AUTOSELECT=NO,DEFAULT=NO
+#EXT-X-STREAM-INF:BANDWIDTH=160000,CODECS="mp4a.40.2",VIDEO="audio_only"
+audio_only/index.m3u8
+`
+
+	playlist, err := ParseMasterPlaylist(content)
+	if err != nil {
+		t.Fatalf("ParseMasterPlaylist() error = %v", err)
+	}
+	if err := playlist.ResolveReferences("https://video.example.com/api/channel/hls/xqc.m3u8"); err != nil {
+		t.Fatalf("ResolveReferences() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		quality     string
+		url         string
+		bandwidth   int
+		height      int
+		frameRate   float64
+		isSource    bool
+		isAudioOnly bool
+	}{
+		{"1080p60 (source)", "1080p60", "https://video.example.com/chunked/index.m3u8", 6000000, 1080, 60, true, false},
+		{"720p", "720p", "https://video.example.com/api/channel/hls/720p30/index.m3u8", 2500000, 720, 30, false, false},
+		{"480p60", "480p60", "https://video.example.com/api/channel/hls/480p60/index.m3u8", 1200000, 480, 60, false, false},
+		{"audio_only", "audio_only", "https://video.example.com/api/channel/hls/audio_only/index.m3u8", 160000, 0, 0, false, true},
+	}
+	if len(playlist.Variants) != len(tests) {
+		t.Fatalf("got %d variants, want %d", len(playlist.Variants), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant := playlist.Variants[i]
+			if variant.Name != tt.name {
+				t.Errorf("Name = %s, want %s", variant.Name, tt.name)
+			}
+			if variant.Quality() != tt.quality {
+				t.Errorf("Quality() = %s, want %s", variant.Quality(), tt.quality)
+			}
+			if variant.Url != tt.url {
+				t.Errorf("Url = %s, want %s", variant.Url, tt.url)
+			}
+			if variant.Bandwidth != tt.bandwidth {
+				t.Errorf("Bandwidth = %d, want %d", variant.Bandwidth, tt.bandwidth)
+			}
+			if variant.Height != tt.height {
+				t.Errorf("Height = %d, want %d", variant.Height, tt.height)
+			}
+			if variant.FrameRate != tt.frameRate {
+				t.Errorf("FrameRate = %v, want %v", variant.FrameRate, tt.frameRate)
+			}
+			if variant.IsSource != tt.isSource {
+				t.Errorf("IsSource = %v, want %v", variant.IsSource, tt.isSource)
+			}
+			if variant.IsAudioOnly != tt.isAudioOnly {
+				t.Errorf("IsAudioOnly = %v, want %v", variant.IsAudioOnly, tt.isAudioOnly)
+			}
+		})
+	}
+}
+
+func TestParseMasterPlaylistErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not a playlist", "<html></html>"},
+		{"no variants", "#EXTM3U\n#EXT-X-VERSION:3\n"},
+		{"variant without uri", "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000,RESOLUTION=640x360\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMasterPlaylist(tt.content); err == nil {
+				t.Error("ParseMasterPlaylist() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseAttributeList(t *testing.T) {
+	attributes := ParseAttributeList(`BANDWIDTH=1000,CODECS="avc1.4D401F,mp4a.40.2",NAME="720p"`)
+
+	tests := map[string]string{
+		"BANDWIDTH": "1000",
+		"CODECS":    "avc1.4D401F,mp4a.40.2",
+		"NAME":      "720p",
+	}
+	for key, want := range tests {
+		if attributes[key] != want {
+			t.Errorf("attributes[%s] = %s, want %s", key, attributes[key], want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package hls
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	targetDurationTag  = "#EXT-X-TARGETDURATION:"
+	mediaSequenceTag   = "#EXT-X-MEDIA-SEQUENCE:"
+	extInfTag          = "#EXTINF:"
+	programDateTimeTag = "#EXT-X-PROGRAM-DATE-TIME:"
+	dateRangeTag       = "#EXT-X-DATERANGE:"
+	mapTag             = "#EXT-X-MAP:"
+	discontinuityTag   = "#EXT-X-DISCONTINUITY"
+	endListTag         = "#EXT-X-ENDLIST"
+
+	// StitchedAdClass is the daterange class Twitch marks its server side inserted ads with
+	StitchedAdClass = "twitch-stitched-ad"
+)
+
+type MediaPlaylist struct {
+	TargetDuration float64
+	MediaSequence  int
+	Segments       []Segment
+	DateRanges     []DateRange
+	EndList        bool
+}
+
+type Segment struct {
+	Sequence        int
+	Url             string
+	Duration        float64
+	Title           string
+	ProgramDateTime time.Time
+	Discontinuity   bool
+	IsAd            bool
+	// MapUrl is the initialization section of fragmented MP4 segments, empty for
+	// transport stream ones
+	MapUrl string
+}
+
+type DateRange struct {
+	Id        string
+	Class     string
+	StartDate time.Time
+	EndDate   time.Time
+	Duration  float64
+}
+
+// ParseMediaPlaylist reads the segments of an HLS media playlist, resolving their
+// uris against the playlist url and flagging the ones that belong to an ad break.
+func ParseMediaPlaylist(content string, playlistUrl string) (*MediaPlaylist, error) {
+	rows := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(rows) == 0 || strings.TrimSpace(rows[0]) != "#EXTM3U" {
+		return nil, errors.New("not an HLS playlist")
+	}
+
+	base, err := url.Parse(playlistUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist := &MediaPlaylist{Segments: make([]Segment, 0), DateRanges: make([]DateRange, 0)}
+	segment := Segment{}
+	mapUrl := ""
+	isMediaPlaylist := false
+
+	for _, row := range rows {
+		row = strings.TrimSpace(row)
+
+		switch {
+		case row == "":
+			continue
+		case strings.HasPrefix(row, streamInfTag):
+			return nil, errors.New("master playlist given instead of a media playlist")
+		case strings.HasPrefix(row, targetDurationTag):
+			playlist.TargetDuration, _ = strconv.ParseFloat(strings.TrimPrefix(row, targetDurationTag), 64)
+			isMediaPlaylist = true
+		case strings.HasPrefix(row, mediaSequenceTag):
+			playlist.MediaSequence, _ = strconv.Atoi(strings.TrimPrefix(row, mediaSequenceTag))
+		case strings.HasPrefix(row, extInfTag):
+			duration, title, _ := strings.Cut(strings.TrimPrefix(row, extInfTag), ",")
+			segment.Duration, _ = strconv.ParseFloat(strings.TrimSpace(duration), 64)
+			segment.Title = strings.TrimSpace(title)
+		case strings.HasPrefix(row, programDateTimeTag):
+			segment.ProgramDateTime, _ = time.Parse(time.RFC3339Nano, strings.TrimPrefix(row, programDateTimeTag))
+		case strings.HasPrefix(row, mapTag):
+			reference, err := url.Parse(ParseAttributeList(strings.TrimPrefix(row, mapTag))["URI"])
+			if err != nil {
+				return nil, err
+			}
+			mapUrl = base.ResolveReference(reference).String()
+		case strings.HasPrefix(row, dateRangeTag):
+			playlist.DateRanges = append(playlist.DateRanges, parseDateRange(strings.TrimPrefix(row, dateRangeTag)))
+		case row == discontinuityTag:
+			segment.Discontinuity = true
+		case row == endListTag:
+			playlist.EndList = true
+		case strings.HasPrefix(row, "#"):
+			continue
+		default:
+			reference, err := url.Parse(row)
+			if err != nil {
+				return nil, err
+			}
+			segment.Url = base.ResolveReference(reference).String()
+			segment.Sequence = playlist.MediaSequence + len(playlist.Segments)
+			segment.MapUrl = mapUrl
+			// A program date time carries over to the segments following it
+			if segment.ProgramDateTime.IsZero() && len(playlist.Segments) > 0 {
+				previous := playlist.Segments[len(playlist.Segments)-1]
+				if !previous.ProgramDateTime.IsZero() {
+					segment.ProgramDateTime = previous.ProgramDateTime.Add(time.Duration(previous.Duration * float64(time.Second)))
+				}
+			}
+			playlist.Segments = append(playlist.Segments, segment)
+			segment = Segment{}
+		}
+	}
+
+	if !isMediaPlaylist {
+		return nil, errors.New("playlist has no target duration")
+	}
+
+	for i := range playlist.Segments {
+		playlist.Segments[i].IsAd = playlist.isAd(&playlist.Segments[i])
+	}
+
+	return playlist, nil
+}
+
+// isAd reports whether the segment is part of a stitched ad, either by its title or by
+// its program date time falling within an ad daterange.
+func (p *MediaPlaylist) isAd(segment *Segment) bool {
+	if strings.HasPrefix(segment.Title, "Amazon") {
+		return true
+	}
+	if segment.ProgramDateTime.IsZero() {
+		return false
+	}
+
+	for _, dateRange := range p.DateRanges {
+		if dateRange.IsAd() && dateRange.Contains(segment.ProgramDateTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DateRange) IsAd() bool {
+	return d.Class == StitchedAdClass || strings.HasPrefix(d.Id, "stitched-ad")
+}
+
+// Contains reports whether the time falls within the daterange, which ends after its
+// duration or at its end date. A daterange of unknown length contains nothing.
+func (d *DateRange) Contains(t time.Time) bool {
+	if d.StartDate.IsZero() {
+		return false
+	}
+
+	end := d.EndDate
+	if d.Duration > 0 {
+		end = d.StartDate.Add(time.Duration(d.Duration * float64(time.Second)))
+	}
+	return !t.Before(d.StartDate) && t.Before(end)
+}
+
+func parseDateRange(list string) DateRange {
+	attributes := ParseAttributeList(list)
+	dateRange := DateRange{Id: attributes["ID"], Class: attributes["CLASS"]}
+	dateRange.StartDate, _ = time.Parse(time.RFC3339Nano, attributes["START-DATE"])
+	dateRange.EndDate, _ = time.Parse(time.RFC3339Nano, attributes["END-DATE"])
+	dateRange.Duration, _ = strconv.ParseFloat(attributes["DURATION"], 64)
+	return dateRange
+}
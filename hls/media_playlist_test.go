@@ -0,0 +1,207 @@
+package hls
+
+import (
+	"testing"
+	"time"
+)
+
+const playlistUrl = "https://video.example.com/live/index.m3u8"
+
+func TestParseMediaPlaylist(t *testing.T) {
+	content := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:2
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:00.000Z
+#EXTINF:2.000,live
+segment100.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:2.000,live
+https://cdn.example.com/segment101.ts
+#EXT-X-ENDLIST
+`
+
+	playlist, err := ParseMediaPlaylist(content, playlistUrl)
+	if err != nil {
+		t.Fatalf("ParseMediaPlaylist() error = %v", err)
+	}
+
+	if playlist.TargetDuration != 2 || playlist.MediaSequence != 100 || !playlist.EndList {
+		t.Errorf("playlist = %+v, want target duration 2, media sequence 100 and end list", playlist)
+	}
+	if len(playlist.Segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(playlist.Segments))
+	}
+
+	tests := []struct {
+		segment         Segment
+		url             string
+		sequence        int
+		programDateTime time.Time
+		discontinuity   bool
+	}{
+		{playlist.Segments[0], "https://video.example.com/live/segment100.ts", 100, time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC), false},
+		{playlist.Segments[1], "https://cdn.example.com/segment101.ts", 101, time.Date(2024, 5, 1, 20, 0, 2, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if tt.segment.Url != tt.url {
+			t.Errorf("segment %d url = %s, want %s", tt.sequence, tt.segment.Url, tt.url)
+		}
+		if tt.segment.Sequence != tt.sequence {
+			t.Errorf("segment %s sequence = %d, want %d", tt.url, tt.segment.Sequence, tt.sequence)
+		}
+		if !tt.segment.ProgramDateTime.Equal(tt.programDateTime) {
+			t.Errorf("segment %d program date time = %v, want %v", tt.sequence, tt.segment.ProgramDateTime, tt.programDateTime)
+		}
+		if tt.segment.Discontinuity != tt.discontinuity {
+			t.Errorf("segment %d discontinuity = %v, want %v", tt.sequence, tt.segment.Discontinuity, tt.discontinuity)
+		}
+	}
+}
+
+func TestParseMediaPlaylistMap(t *testing.T) {
+	content := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:2
+#EXT-X-MAP:URI="init0.mp4"
+#EXTINF:2.000,
+segment0.m4s
+#EXTINF:2.000,
+segment1.m4s
+#EXT-X-DISCONTINUITY
+#EXT-X-MAP:URI="https://cdn.example.com/init1.mp4"
+#EXTINF:2.000,
+segment2.m4s
+`
+
+	playlist, err := ParseMediaPlaylist(content, playlistUrl)
+	if err != nil {
+		t.Fatalf("ParseMediaPlaylist() error = %v", err)
+	}
+
+	want := []string{
+		"https://video.example.com/live/init0.mp4",
+		"https://video.example.com/live/init0.mp4",
+		"https://cdn.example.com/init1.mp4",
+	}
+	if len(playlist.Segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(playlist.Segments), len(want))
+	}
+	for i, segment := range playlist.Segments {
+		if segment.MapUrl != want[i] {
+			t.Errorf("segment %d MapUrl = %s, want %s", i, segment.MapUrl, want[i])
+		}
+	}
+}
+
+func TestParseMediaPlaylistErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not a playlist", "<html></html>"},
+		{"master playlist", "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000\nvariant.m3u8\n"},
+		{"no target duration", "#EXTM3U\n#EXTINF:2.000,\nsegment.ts\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMediaPlaylist(tt.content, playlistUrl); err == nil {
+				t.Error("ParseMediaPlaylist() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseMediaPlaylistAds(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		isAd    []bool
+	}{
+		{
+			name: "segments within the ad duration",
+			content: `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-DATERANGE:ID="stitched-ad-1",CLASS="twitch-stitched-ad",START-DATE="2024-05-01T20:00:02.000Z",DURATION=4.000
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:00.000Z
+#EXTINF:2.000,live
+segment0.ts
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:02.000Z
+#EXTINF:2.000,Amazon|123
+segment1.ts
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:04.000Z
+#EXTINF:2.000,
+segment2.ts
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:06.000Z
+#EXTINF:2.000,live
+segment3.ts
+`,
+			isAd: []bool{false, true, true, false},
+		},
+		{
+			name: "segments within the ad end date",
+			content: `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-DATERANGE:ID="stitched-ad-1",START-DATE="2024-05-01T20:00:00.000Z",END-DATE="2024-05-01T20:00:02.000Z"
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:00.000Z
+#EXTINF:2.000,
+segment0.ts
+#EXTINF:2.000,
+segment1.ts
+`,
+			isAd: []bool{true, false},
+		},
+		{
+			name: "titled segments outside the ad",
+			content: `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-DATERANGE:ID="stitched-ad-1",CLASS="twitch-stitched-ad",START-DATE="2024-05-01T19:00:00.000Z",DURATION=30.000
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:00.000Z
+#EXTINF:2.000,stream
+segment0.ts
+#EXTINF:2.000,
+segment1.ts
+`,
+			isAd: []bool{false, false},
+		},
+		{
+			name: "daterange that is not an ad",
+			content: `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-DATERANGE:ID="source-1",CLASS="twitch-stream-source",START-DATE="2024-05-01T20:00:00.000Z",DURATION=30.000
+#EXT-X-PROGRAM-DATE-TIME:2024-05-01T20:00:00.000Z
+#EXTINF:2.000,live
+segment0.ts
+`,
+			isAd: []bool{false},
+		},
+		{
+			name: "ad title without a daterange",
+			content: `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXTINF:2.000,Amazon|123
+segment0.ts
+#EXTINF:2.000,live
+segment1.ts
+`,
+			isAd: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist, err := ParseMediaPlaylist(tt.content, playlistUrl)
+			if err != nil {
+				t.Fatalf("ParseMediaPlaylist() error = %v", err)
+			}
+			if len(playlist.Segments) != len(tt.isAd) {
+				t.Fatalf("got %d segments, want %d", len(playlist.Segments), len(tt.isAd))
+			}
+			for i, segment := range playlist.Segments {
+				if segment.IsAd != tt.isAd[i] {
+					t.Errorf("segment %d IsAd = %v, want %v", i, segment.IsAd, tt.isAd[i])
+				}
+			}
+		})
+	}
+}
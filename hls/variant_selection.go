@@ -0,0 +1,129 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const SourceQuality = "source"
+
+type Selection struct {
+	Variant   Variant
+	Requested string
+	Fallback  bool
+}
+
+// SelectVariant returns the first variant matching the ordered quality preferences
+// (e.g. "480p", "720p60", "source", "audio_only"). When none of them is available the
+// video variant closest to the first preference is picked instead.
+func SelectVariant(variants []Variant, preferences []string) (*Selection, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("no variants available")
+	}
+
+	requested := ""
+	if len(preferences) > 0 {
+		requested = preferences[0]
+	}
+
+	if len(variants) == 1 {
+		return &Selection{Variant: variants[0], Requested: requested}, nil
+	}
+
+	for i, preference := range preferences {
+		for _, variant := range variants {
+			if variant.matches(preference) {
+				return &Selection{Variant: variant, Requested: requested, Fallback: i > 0}, nil
+			}
+		}
+	}
+
+	return &Selection{Variant: closestVariant(variants, requested), Requested: requested, Fallback: requested != ""}, nil
+}
+
+func (s *Selection) Description() string {
+	if s.Fallback {
+		return fmt.Sprintf("Playing %s (%s unavailable)", s.Variant.Quality(), s.Requested)
+	}
+	return "Playing " + s.Variant.Quality()
+}
+
+func (v *Variant) matches(preference string) bool {
+	switch preference {
+	case AudioOnlyGroupId:
+		return v.IsAudioOnly
+	case SourceQuality:
+		return v.IsSource
+	}
+
+	quality := v.Quality()
+	if quality == preference {
+		return true
+	}
+
+	// "480p" matches "480p30" and a 852x480 variant, but "720p60" only matches 60fps renditions
+	height, frameRate := parseQuality(preference)
+	if height == 0 || v.IsAudioOnly || v.Height != height {
+		return false
+	}
+	return frameRate == 0 || int(v.FrameRate+0.5) == frameRate
+}
+
+func closestVariant(variants []Variant, requested string) Variant {
+	requestedHeight, _ := parseQuality(requested)
+	closest := -1
+	for i, variant := range variants {
+		if variant.IsAudioOnly {
+			continue
+		}
+		if closest == -1 || distance(variant.Height, requestedHeight) < distance(variants[closest].Height, requestedHeight) ||
+			(distance(variant.Height, requestedHeight) == distance(variants[closest].Height, requestedHeight) && variant.Bandwidth < variants[closest].Bandwidth) {
+			closest = i
+		}
+	}
+
+	if closest == -1 {
+		return variants[0]
+	}
+	return variants[closest]
+}
+
+// AlternateVariants returns the other video variants ordered by how close they are to
+// the current one, the fallbacks when the current variant cannot be played.
+func AlternateVariants(variants []Variant, current Variant) []Variant {
+	alternates := make([]Variant, 0, len(variants))
+	for _, variant := range variants {
+		if variant.Url != current.Url && !variant.IsAudioOnly {
+			alternates = append(alternates, variant)
+		}
+	}
+
+	sort.SliceStable(alternates, func(i, j int) bool {
+		return distance(alternates[i].Height, current.Height) < distance(alternates[j].Height, current.Height)
+	})
+	return alternates
+}
+
+func parseQuality(quality string) (height int, frameRate int) {
+	heightText, frameRateText, found := strings.Cut(quality, "p")
+	if !found {
+		return 0, 0
+	}
+
+	height, err := strconv.Atoi(heightText)
+	if err != nil {
+		return 0, 0
+	}
+	frameRate, _ = strconv.Atoi(frameRateText)
+	return height, frameRate
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
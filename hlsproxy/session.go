@@ -0,0 +1,497 @@
+package hlsproxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/hls"
+)
+
+const (
+	AdBreakStatus = "Ad break — waiting for stream"
+
+	maxPlaylistSize = 1024 * 1024
+	// bandwidthSmoothing is the weight of the latest segment in the bandwidth average
+	bandwidthSmoothing = 0.3
+)
+
+// Session follows the media playlist of one live stream, downloads its segments and
+// serves them to the player as a local HLS stream.
+type Session struct {
+	Config      config.HlsProxyConfig
+	PlaylistUrl string
+	// Alternates are the media playlists of the other variants, best first, to fall
+	// back to during ad breaks
+	Alternates []string
+	// OnAdBreak is called when an ad break holds back the start of the playback
+	OnAdBreak func()
+
+	mu             sync.Mutex
+	segments       []*bufferedSegment
+	lastSequence   int
+	localSequence  int
+	targetDuration float64
+	ended          bool
+	stats          Stats
+	// discontinuity marks the next buffered segment, after skipped ads or a variant switch
+	discontinuity bool
+	// maps are the downloaded initialization sections of the buffered fragmented MP4
+	// segments, by the local number they are served under
+	maps       map[int][]byte
+	lastMapUrl string
+	localMap   int
+
+	listener net.Listener
+	server   *http.Server
+	done     chan struct{}
+	closed   sync.Once
+}
+
+// bufferedSegment is a downloaded segment, numbered with the local sequence the proxy
+// serves it under so segments that failed to download leave no gaps.
+type bufferedSegment struct {
+	hls.Segment
+	localSequence int
+	// localMap is the initialization section of the segment, -1 when it has none
+	localMap int
+	data     []byte
+}
+
+func NewSession(cfg config.HlsProxyConfig, playlistUrl string, alternates []string) *Session {
+	return &Session{
+		Config:       cfg,
+		PlaylistUrl:  playlistUrl,
+		Alternates:   alternates,
+		segments:     make([]*bufferedSegment, 0),
+		lastSequence: -1,
+		maps:         make(map[int][]byte),
+		localMap:     -1,
+		done:         make(chan struct{}),
+	}
+}
+
+// Start fetches the media playlist and buffers the segments at its live edge before
+// serving them, it fails when the url is not an HLS media playlist.
+func (s *Session) Start() error {
+	playlist, err := s.fetchPlaylist()
+	if err != nil {
+		return err
+	}
+
+	// Begin close to the live edge instead of replaying the whole playlist window
+	if edge := len(playlist.Segments) - s.Config.LiveEdgeSegments; edge > 0 {
+		s.lastSequence = playlist.Segments[edge-1].Sequence
+	}
+	playlist = s.process(playlist)
+
+	// Streams often start with an ad break, wait for it to pass before starting the player
+	waitUntil := time.Now().Add(s.Config.AdWaitTimeout)
+	for {
+		stats := s.Stats()
+		if stats.BufferedSegments > 0 || playlist.EndList {
+			break
+		}
+		if !stats.SkippingAd {
+			return errors.New("no segment could be downloaded")
+		}
+		if time.Now().After(waitUntil) {
+			return errors.New("ad break did not end in time")
+		}
+		if s.OnAdBreak != nil {
+			s.OnAdBreak()
+		}
+
+		if err := s.sleep(playlist.TargetDuration / 2); err != nil {
+			return err
+		}
+		if playlist, err = s.fetchPlaylist(); err != nil {
+			return err
+		}
+		playlist = s.process(playlist)
+	}
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /playlist.m3u8", s.servePlaylist)
+	mux.HandleFunc("GET /segment/{name}", s.serveSegment)
+	mux.HandleFunc("GET /map/{name}", s.serveMap)
+	s.server = &http.Server{Handler: mux}
+
+	go s.server.Serve(s.listener)
+	go s.poll(playlist)
+	return nil
+}
+
+// Url returns the local playlist url to hand to the player.
+func (s *Session) Url() string {
+	return fmt.Sprintf("http://%s/playlist.m3u8", s.listener.Addr().String())
+}
+
+func (s *Session) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.BufferedSegments = len(s.segments)
+	for _, segment := range s.segments {
+		stats.BufferedSeconds += segment.Duration
+	}
+	return stats
+}
+
+func (s *Session) Close() {
+	s.closed.Do(func() {
+		close(s.done)
+		if s.server != nil {
+			s.server.Close()
+		}
+	})
+}
+
+// poll refreshes the media playlist about twice per target duration until the
+// stream ends or the session is closed.
+func (s *Session) poll(playlist *hls.MediaPlaylist) {
+	for !playlist.EndList {
+		if err := s.sleep(playlist.TargetDuration / 2); err != nil {
+			return
+		}
+
+		next, err := s.fetchPlaylist()
+		if err != nil {
+			log.Printf("Giving up on media playlist %s: %v", s.PlaylistUrl, err)
+			break
+		}
+		playlist = s.process(next)
+	}
+
+	s.mu.Lock()
+	s.ended = true
+	s.mu.Unlock()
+}
+
+// sleep waits for the given number of seconds, at least half a second, unless the
+// session gets closed.
+func (s *Session) sleep(seconds float64) error {
+	select {
+	case <-s.done:
+		return errors.New("session closed")
+	case <-time.After(max(time.Duration(seconds*float64(time.Second)), 500*time.Millisecond)):
+		return nil
+	}
+}
+
+// process downloads the segments that are new since the last refresh, holding back
+// ad segments when configured to. During an ad break it may switch to an alternate
+// variant without ads, the playlist being followed from then on is returned.
+func (s *Session) process(playlist *hls.MediaPlaylist) *hls.MediaPlaylist {
+	s.download(playlist)
+
+	s.mu.Lock()
+	switchVariant := s.stats.SkippingAd && s.Config.SwitchVariantOnAds && len(s.Alternates) > 0
+	s.mu.Unlock()
+
+	if switchVariant {
+		if alternate := s.switchVariant(); alternate != nil {
+			s.download(alternate)
+			return alternate
+		}
+	}
+	return playlist
+}
+
+// switchVariant moves to the first alternate variant whose live edge is not an ad.
+func (s *Session) switchVariant() *hls.MediaPlaylist {
+	for i, alternateUrl := range s.Alternates {
+		content, err := s.fetch(alternateUrl, maxPlaylistSize)
+		if err != nil {
+			continue
+		}
+		alternate, err := hls.ParseMediaPlaylist(string(content), alternateUrl)
+		if err != nil || len(alternate.Segments) == 0 || alternate.Segments[len(alternate.Segments)-1].IsAd {
+			continue
+		}
+
+		log.Printf("Ad break on %s, switching to %s", s.PlaylistUrl, alternateUrl)
+
+		s.mu.Lock()
+		s.Alternates = append(append(s.Alternates[:i:i], s.Alternates[i+1:]...), s.PlaylistUrl)
+		s.PlaylistUrl = alternateUrl
+		s.lastSequence = -1
+		if edge := len(alternate.Segments) - s.Config.LiveEdgeSegments; edge > 0 {
+			s.lastSequence = alternate.Segments[edge-1].Sequence
+		}
+		s.discontinuity = true
+		s.stats.VariantSwitches++
+		s.mu.Unlock()
+
+		return alternate
+	}
+	return nil
+}
+
+func (s *Session) download(playlist *hls.MediaPlaylist) {
+	s.mu.Lock()
+	s.targetDuration = playlist.TargetDuration
+	lastSequence := s.lastSequence
+	s.mu.Unlock()
+
+	for _, segment := range playlist.Segments {
+		if segment.Sequence <= lastSequence {
+			continue
+		}
+
+		if segment.IsAd && s.Config.SkipAds {
+			s.mu.Lock()
+			s.lastSequence = segment.Sequence
+			s.stats.AdSegments++
+			s.stats.InAd = true
+			s.stats.SkippingAd = true
+			s.discontinuity = true
+			s.mu.Unlock()
+			continue
+		}
+
+		mapData, err := s.downloadMap(segment.MapUrl)
+		if err != nil {
+			log.Printf("An error occurred while downloading the initialization section of segment %d: %v", segment.Sequence, err)
+			s.mu.Lock()
+			s.lastSequence = segment.Sequence
+			s.stats.FailedSegments++
+			s.mu.Unlock()
+			continue
+		}
+
+		startedAt := time.Now()
+		data, err := s.fetchWithRetries(segment.Url, 0)
+
+		s.mu.Lock()
+		s.lastSequence = segment.Sequence
+		if err != nil {
+			log.Printf("An error occurred while downloading segment %d: %v", segment.Sequence, err)
+			s.stats.FailedSegments++
+			s.mu.Unlock()
+			continue
+		}
+
+		if mapData != nil {
+			s.localMap++
+			s.maps[s.localMap] = mapData
+			s.lastMapUrl = segment.MapUrl
+		}
+		localMap := -1
+		if segment.MapUrl != "" {
+			localMap = s.localMap
+		}
+
+		s.updateBandwidth(len(data), time.Since(startedAt))
+		s.stats.DownloadedSegments++
+		s.stats.InAd = segment.IsAd
+		s.stats.SkippingAd = false
+		if segment.IsAd {
+			s.stats.AdSegments++
+		}
+		if s.discontinuity {
+			segment.Discontinuity = true
+			s.discontinuity = false
+		}
+
+		s.segments = append(s.segments, &bufferedSegment{Segment: segment, localSequence: s.localSequence, localMap: localMap, data: data})
+		s.localSequence++
+		if len(s.segments) > s.Config.BufferSegments {
+			s.segments = s.segments[len(s.segments)-s.Config.BufferSegments:]
+			s.pruneMaps()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// downloadMap downloads the initialization section at the url when it differs from the
+// one of the previous segment, returning nil when there is nothing new to download.
+func (s *Session) downloadMap(mapUrl string) ([]byte, error) {
+	s.mu.Lock()
+	lastMapUrl := s.lastMapUrl
+	s.mu.Unlock()
+
+	if mapUrl == "" || mapUrl == lastMapUrl {
+		return nil, nil
+	}
+	return s.fetchWithRetries(mapUrl, 0)
+}
+
+// pruneMaps drops the initialization sections no buffered segment uses anymore.
+func (s *Session) pruneMaps() {
+	for localMap := range s.maps {
+		if len(s.segments) == 0 || localMap < s.segments[0].localMap {
+			delete(s.maps, localMap)
+		}
+	}
+}
+
+func (s *Session) updateBandwidth(size int, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+
+	bandwidth := float64(size*8) / elapsed.Seconds()
+	if s.stats.Bandwidth == 0 {
+		s.stats.Bandwidth = bandwidth
+	} else {
+		s.stats.Bandwidth = bandwidthSmoothing*bandwidth + (1-bandwidthSmoothing)*s.stats.Bandwidth
+	}
+}
+
+func (s *Session) fetchPlaylist() (*hls.MediaPlaylist, error) {
+	s.mu.Lock()
+	playlistUrl := s.PlaylistUrl
+	s.mu.Unlock()
+
+	content, err := s.fetchWithRetries(playlistUrl, maxPlaylistSize)
+	if err != nil {
+		return nil, err
+	}
+	return hls.ParseMediaPlaylist(string(content), playlistUrl)
+}
+
+// fetchWithRetries downloads the url, retrying with a growing delay. A positive
+// limit caps the number of bytes read.
+func (s *Session) fetchWithRetries(url string, limit int64) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= s.Config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-s.done:
+				return nil, errors.New("session closed")
+			case <-time.After(time.Duration(attempt) * s.Config.RetryDelay):
+			}
+		}
+
+		var data []byte
+		data, err = s.fetch(url, limit)
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, err
+}
+
+func (s *Session) fetch(url string, limit int64) ([]byte, error) {
+	resp, err := s.Config.HttpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit)
+	}
+	return io.ReadAll(body)
+}
+
+func (s *Session) servePlaylist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Initialization sections need version 6
+	version := 3
+	if len(s.maps) > 0 {
+		version = 6
+	}
+
+	var playlist strings.Builder
+	playlist.WriteString(fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:%d\n", version))
+	playlist.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(s.targetDuration))))
+
+	mediaSequence := s.localSequence
+	if len(s.segments) > 0 {
+		mediaSequence = s.segments[0].localSequence
+	}
+	playlist.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence))
+
+	localMap := -1
+	for _, segment := range s.segments {
+		if segment.Discontinuity {
+			playlist.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if segment.localMap != localMap && segment.localMap >= 0 {
+			playlist.WriteString(fmt.Sprintf("#EXT-X-MAP:URI=\"map/%d.mp4\"\n", segment.localMap))
+		}
+		localMap = segment.localMap
+		playlist.WriteString(fmt.Sprintf("#EXTINF:%.3f,\nsegment/%d.ts\n", segment.Duration, segment.localSequence))
+	}
+
+	if s.ended {
+		playlist.WriteString("#EXT-X-ENDLIST\n")
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	io.WriteString(w, playlist.String())
+}
+
+func (s *Session) serveSegment(w http.ResponseWriter, r *http.Request) {
+	sequence, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("name"), ".ts"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	var data []byte
+	contentType := "video/mp2t"
+	for _, segment := range s.segments {
+		if segment.localSequence == sequence {
+			data = segment.data
+			if segment.localMap >= 0 {
+				contentType = "video/mp4"
+			}
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+func (s *Session) serveMap(w http.ResponseWriter, r *http.Request) {
+	localMap, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("name"), ".mp4"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	data := s.maps[localMap]
+	s.mu.Unlock()
+
+	if data == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Write(data)
+}
@@ -0,0 +1,34 @@
+package hlsproxy
+
+import "fmt"
+
+type Stats struct {
+	BufferedSegments int
+	BufferedSeconds  float64
+	// Bandwidth is the average download speed of the segments in bits per second
+	Bandwidth          float64
+	DownloadedSegments int
+	FailedSegments     int
+	AdSegments         int
+	// InAd is set while the latest segment belongs to an ad break
+	InAd bool
+	// SkippingAd is set while ad segments are held back from the player
+	SkippingAd      bool
+	VariantSwitches int
+}
+
+// String returns a one line summary, e.g. "Buffer 6.0s | 3.2 Mbps | 1 failed".
+func (s Stats) String() string {
+	if s.SkippingAd {
+		return AdBreakStatus
+	}
+
+	text := fmt.Sprintf("Buffer %.1fs | %.1f Mbps", s.BufferedSeconds, s.Bandwidth/1000000)
+	if s.FailedSegments > 0 {
+		text += fmt.Sprintf(" | %d failed", s.FailedSegments)
+	}
+	if s.InAd {
+		text += " | Ad"
+	}
+	return text
+}
@@ -38,6 +38,14 @@ func (s *KeyboardMapperStrategy) MapInputToKey(event sdl.Event) Key {
 			return B
 		case sdl.K_ESCAPE:
 			return B
+		case sdl.K_TAB:
+			return Select
+		case sdl.K_SPACE:
+			return Start
+		case sdl.K_PAGEUP:
+			return L1
+		case sdl.K_PAGEDOWN:
+			return R1
 		default:
 			return Unknown
 		}
@@ -68,6 +76,14 @@ func (s *JoyButtonMapperStrategy) MapInputToKey(event sdl.Event) Key {
 		return Y
 	case 6: // X button
 		return X
+	case 7: // L1 button
+		return L1
+	case 8: // R1 button
+		return R1
+	case 9: // Select button
+		return Select
+	case 10: // Start button
+		return Start
 	default:
 		return Unknown
 	}
@@ -15,6 +15,10 @@ const (
 	B
 	X
 	Y
+	Select
+	Start
+	L1
+	R1
 	Unknown
 )
 
@@ -0,0 +1,329 @@
+package iptv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+const (
+	ProviderName = "iptv"
+
+	// liveCheckTimeout bounds how long a channel url may take to answer before the
+	// channel is considered offline
+	liveCheckTimeout = 3 * time.Second
+)
+
+type IptvConfig struct {
+	// PlaylistSource is a path on disk or an http(s) url of an extended M3U playlist
+	PlaylistSource string
+	PageSize       int
+	HttpClient     *http.Client
+	// PlaylistTtl is how long the channels are kept before the playlist is read again
+	PlaylistTtl time.Duration
+}
+
+// IptvService presents the channels of an M3U playlist as live streams and its
+// group titles as categories.
+type IptvService struct {
+	Config   IptvConfig
+	mu       sync.Mutex
+	channels []Channel
+	loadedAt time.Time
+}
+
+func (s *IptvService) Name() string {
+	return ProviderName
+}
+
+func (s *IptvService) GetTopStreams(cursor string) ([]model.Stream, error) {
+	channels, err := s.getChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.page(channels, cursor)
+}
+
+func (s *IptvService) SearchStreams(query string, cursor string) ([]model.Stream, error) {
+	channels, err := s.getChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	matches := make([]Channel, 0)
+	for _, channel := range channels {
+		if strings.Contains(strings.ToLower(channel.Name), query) || strings.Contains(strings.ToLower(channel.Group), query) {
+			matches = append(matches, channel)
+		}
+	}
+
+	return s.page(matches, cursor)
+}
+
+// GetStreamPlaylist returns the variants of the channel when its url is an HLS master
+// playlist, or the channel url itself as the only variant otherwise.
+func (s *IptvService) GetStreamPlaylist(login string) (*hls.MasterPlaylist, error) {
+	channel, err := s.findChannel(login)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := &hls.MasterPlaylist{Variants: []hls.Variant{{Name: hls.SourceQuality, Url: channel.Url, IsSource: true}}}
+	if !strings.HasPrefix(channel.Url, "http") {
+		return direct, nil
+	}
+
+	resp, err := s.Config.HttpClient.Get(channel.Url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("channel %s responded with status %d", channel.Name, resp.StatusCode)
+	}
+
+	// Only peek at the beginning, the url might as well be an endless transport stream
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	playlist, err := hls.ParseMasterPlaylist(string(body))
+	if err != nil {
+		return direct, nil
+	}
+
+	if err := playlist.ResolveReferences(resp.Request.URL.String()); err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// GetChannelInfo reports the channel as live while its url answers, playlists having no
+// other way to tell that a channel is gone.
+func (s *IptvService) GetChannelInfo(login string) (*model.Channel, error) {
+	channel, err := s.findChannel(login)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := toStream(*channel)
+	if !s.isReachable(channel.Url) {
+		return &model.Channel{Broadcaster: stream.Broadcaster, IsLive: false}, nil
+	}
+	return &model.Channel{Broadcaster: stream.Broadcaster, IsLive: true, Stream: &stream}, nil
+}
+
+// isReachable reports whether the channel url answers successfully, only reading the
+// response headers since the url might be an endless transport stream. Urls other than
+// http ones cannot be checked and are assumed to be reachable.
+func (s *IptvService) isReachable(url string) bool {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), liveCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := s.Config.HttpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode < http.StatusBadRequest
+}
+
+func (s *IptvService) GetTopCategories() ([]model.Category, error) {
+	channels, err := s.getChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	channelsPerGroup := make(map[string]int)
+	logos := make(map[string]string)
+	for _, channel := range channels {
+		channelsPerGroup[channel.Group]++
+		if logos[channel.Group] == "" {
+			logos[channel.Group] = channel.Logo
+		}
+	}
+
+	categories := make([]model.Category, 0, len(channelsPerGroup))
+	for group, count := range channelsPerGroup {
+		categories = append(categories, model.Category{
+			Id:            group,
+			Name:          group,
+			DisplayName:   groupDisplayName(group),
+			ChannelsCount: count,
+			BoxArtURL:     logos[group],
+		})
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].DisplayName < categories[j].DisplayName
+	})
+
+	return categories, nil
+}
+
+func (s *IptvService) GetCategoryStreams(categoryName string) ([]model.Stream, error) {
+	channels, err := s.getChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	groupChannels := make([]Channel, 0)
+	for _, channel := range channels {
+		if channel.Group == categoryName {
+			groupChannels = append(groupChannels, channel)
+		}
+	}
+
+	return toStreams(groupChannels), nil
+}
+
+func (s *IptvService) getChannels() ([]Channel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channels != nil && time.Since(s.loadedAt) < s.Config.PlaylistTtl {
+		return s.channels, nil
+	}
+
+	content, err := s.readPlaylist()
+	var channels []Channel
+	if err == nil {
+		channels, err = ParsePlaylist(content)
+	}
+	if err != nil {
+		// Keep the channels read last time rather than losing them all to a bad edit
+		if s.channels != nil {
+			log.Printf("An error occurred while reloading playlist %s, keeping the previous channels: %v", s.Config.PlaylistSource, err)
+			s.loadedAt = time.Now()
+			return s.channels, nil
+		}
+		return nil, err
+	}
+
+	s.channels = channels
+	s.loadedAt = time.Now()
+	return channels, nil
+}
+
+func (s *IptvService) readPlaylist() (string, error) {
+	if !strings.HasPrefix(s.Config.PlaylistSource, "http://") && !strings.HasPrefix(s.Config.PlaylistSource, "https://") {
+		data, err := os.ReadFile(s.Config.PlaylistSource)
+		return string(data), err
+	}
+
+	resp, err := s.Config.HttpClient.Get(s.Config.PlaylistSource)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("playlist %s responded with status %d", s.Config.PlaylistSource, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func (s *IptvService) findChannel(login string) (*Channel, error) {
+	channels, err := s.getChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range channels {
+		if channels[i].Id == login {
+			return &channels[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("channel not found in playlist: %s", login)
+}
+
+// page returns the streams of one page of channels, the cursor being the index of the
+// first channel of the page.
+func (s *IptvService) page(channels []Channel, cursor string) ([]model.Stream, error) {
+	start := 0
+	if cursor != "" {
+		var err error
+		start, err = strconv.Atoi(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+	}
+
+	if start >= len(channels) {
+		return make([]model.Stream, 0), nil
+	}
+
+	end := start + s.Config.PageSize
+	if s.Config.PageSize <= 0 || end > len(channels) {
+		end = len(channels)
+	}
+
+	streams := toStreams(channels[start:end])
+	if end < len(channels) {
+		for i := range streams {
+			streams[i].Cursor = strconv.Itoa(end)
+		}
+	}
+
+	return streams, nil
+}
+
+func toStreams(channels []Channel) []model.Stream {
+	streams := make([]model.Stream, 0, len(channels))
+	for _, channel := range channels {
+		streams = append(streams, toStream(channel))
+	}
+	return streams
+}
+
+// toStream maps a channel onto a stream, the channel id doubles as the broadcaster login
+// so channels sharing a name stay apart.
+func toStream(channel Channel) model.Stream {
+	return model.Stream{
+		Id:              channel.Url,
+		Title:           groupDisplayName(channel.Group),
+		PreviewImageURL: channel.Logo,
+		Broadcaster: &model.Broadcaster{
+			Provider:        ProviderName,
+			Id:              channel.TvgId,
+			Login:           channel.Id,
+			DisplayName:     channel.Name,
+			ProfileImageURL: channel.Logo,
+		},
+	}
+}
+
+func groupDisplayName(group string) string {
+	if group == "" {
+		return "Ungrouped"
+	}
+	return group
+}
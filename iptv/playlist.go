@@ -0,0 +1,124 @@
+package iptv
+
+import (
+	"errors"
+	"strings"
+)
+
+const extInfTag = "#EXTINF:"
+
+type Channel struct {
+	// Id identifies the channel within the playlist, its tvg-id or its url when it has
+	// none or shares it with an earlier channel
+	Id    string
+	Name  string
+	Url   string
+	TvgId string
+	Logo  string
+	Group string
+}
+
+// ParsePlaylist reads the channels of an extended M3U playlist, where every
+// stream url is preceded by an #EXTINF line such as
+// #EXTINF:-1 tvg-id="news.uk" tvg-logo="http://logo.png" group-title="News",News Channel
+func ParsePlaylist(content string) ([]Channel, error) {
+	rows := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(rows) == 0 || !strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(rows[0], "\ufeff")), "#EXTM3U") {
+		return nil, errors.New("not an extended M3U playlist")
+	}
+
+	channels := make([]Channel, 0)
+	ids := make(map[string]bool)
+	var current *Channel
+	group := ""
+
+	for _, row := range rows[1:] {
+		row = strings.TrimSpace(row)
+
+		switch {
+		case row == "":
+			continue
+		case strings.HasPrefix(row, extInfTag):
+			current = parseExtInf(strings.TrimPrefix(row, extInfTag))
+		case strings.HasPrefix(row, "#EXTGRP:"):
+			group = strings.TrimSpace(strings.TrimPrefix(row, "#EXTGRP:"))
+		case strings.HasPrefix(row, "#"):
+			continue
+		case current != nil:
+			current.Url = row
+			if current.Group == "" {
+				current.Group = group
+			}
+			current.Id = current.TvgId
+			if current.Id == "" || ids[current.Id] {
+				current.Id = current.Url
+			}
+			ids[current.Id] = true
+			channels = append(channels, *current)
+			current = nil
+			group = ""
+		}
+	}
+
+	if len(channels) == 0 {
+		return nil, errors.New("playlist has no channels")
+	}
+
+	return channels, nil
+}
+
+func parseExtInf(info string) *Channel {
+	attributes, name := splitExtInf(info)
+	channel := &Channel{
+		Name:  strings.TrimSpace(name),
+		TvgId: attributes["tvg-id"],
+		Logo:  attributes["tvg-logo"],
+		Group: attributes["group-title"],
+	}
+
+	if channel.Name == "" {
+		channel.Name = attributes["tvg-name"]
+	}
+	return channel
+}
+
+// splitExtInf separates the space separated key="value" attributes that follow the
+// duration from the channel name after the first comma outside of quotes.
+func splitExtInf(info string) (map[string]string, string) {
+	attributes := make(map[string]string)
+
+	inQuotes := false
+	nameStart := len(info)
+	for i := 0; i < len(info); i++ {
+		if info[i] == '"' {
+			inQuotes = !inQuotes
+		} else if info[i] == ',' && !inQuotes {
+			nameStart = i
+			break
+		}
+	}
+
+	name := ""
+	if nameStart < len(info) {
+		name = info[nameStart+1:]
+	}
+
+	rest := info[:nameStart]
+	for {
+		equals := strings.Index(rest, "=\"")
+		if equals == -1 {
+			break
+		}
+
+		keyStart := strings.LastIndex(rest[:equals], " ") + 1
+		valueEnd := strings.Index(rest[equals+2:], "\"")
+		if valueEnd == -1 {
+			break
+		}
+
+		attributes[strings.ToLower(rest[keyStart:equals])] = rest[equals+2 : equals+2+valueEnd]
+		rest = rest[equals+2+valueEnd+1:]
+	}
+
+	return attributes, name
+}
@@ -0,0 +1,151 @@
+package iptv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Channel
+	}{
+		{
+			name: "attributes and name",
+			content: `#EXTM3U
+#EXTINF:-1 tvg-id="news.uk" tvg-logo="http://logo.example.com/news.png" group-title="News",News Channel
+http://stream.example.com/news.m3u8
+`,
+			want: []Channel{
+				{Id: "news.uk", Name: "News Channel", Url: "http://stream.example.com/news.m3u8", TvgId: "news.uk", Logo: "http://logo.example.com/news.png", Group: "News"},
+			},
+		},
+		{
+			name: "commas inside quoted attributes",
+			content: `#EXTM3U
+#EXTINF:-1 tvg-id="sport" group-title="Sport, Live",Sport, Extra
+http://stream.example.com/sport.m3u8
+`,
+			want: []Channel{
+				{Id: "sport", Name: "Sport, Extra", Url: "http://stream.example.com/sport.m3u8", TvgId: "sport", Group: "Sport, Live"},
+			},
+		},
+		{
+			name: "missing name falls back to tvg-name",
+			content: `#EXTM3U
+#EXTINF:-1 tvg-id="music" tvg-name="Music Channel",
+http://stream.example.com/music.m3u8
+`,
+			want: []Channel{
+				{Id: "music", Name: "Music Channel", Url: "http://stream.example.com/music.m3u8", TvgId: "music"},
+			},
+		},
+		{
+			name: "group from EXTGRP",
+			content: `#EXTM3U
+#EXTINF:-1,Movies One
+#EXTGRP:Movies
+http://stream.example.com/movies1.m3u8
+#EXTINF:-1 group-title="Kids",Movies Two
+#EXTGRP:Movies
+http://stream.example.com/movies2.m3u8
+#EXTINF:-1,Movies Three
+http://stream.example.com/movies3.m3u8
+`,
+			want: []Channel{
+				{Id: "http://stream.example.com/movies1.m3u8", Name: "Movies One", Url: "http://stream.example.com/movies1.m3u8", Group: "Movies"},
+				{Id: "http://stream.example.com/movies2.m3u8", Name: "Movies Two", Url: "http://stream.example.com/movies2.m3u8", Group: "Kids"},
+				{Id: "http://stream.example.com/movies3.m3u8", Name: "Movies Three", Url: "http://stream.example.com/movies3.m3u8"},
+			},
+		},
+		{
+			name:    "byte order mark and windows line endings",
+			content: "\ufeff#EXTM3U\r\n#EXTINF:-1 tvg-id=\"news.uk\",News\r\nhttp://stream.example.com/news.m3u8\r\n",
+			want: []Channel{
+				{Id: "news.uk", Name: "News", Url: "http://stream.example.com/news.m3u8", TvgId: "news.uk"},
+			},
+		},
+		{
+			name: "duplicate and missing tvg-ids use the url",
+			content: `#EXTM3U
+#EXTINF:-1 tvg-id="news",News HD
+http://stream.example.com/news-hd.m3u8
+#EXTINF:-1 tvg-id="news",News SD
+http://stream.example.com/news-sd.m3u8
+#EXTINF:-1,Local
+http://stream.example.com/local.m3u8
+`,
+			want: []Channel{
+				{Id: "news", Name: "News HD", Url: "http://stream.example.com/news-hd.m3u8", TvgId: "news"},
+				{Id: "http://stream.example.com/news-sd.m3u8", Name: "News SD", Url: "http://stream.example.com/news-sd.m3u8", TvgId: "news"},
+				{Id: "http://stream.example.com/local.m3u8", Name: "Local", Url: "http://stream.example.com/local.m3u8"},
+			},
+		},
+		{
+			name: "urls without EXTINF and other tags are skipped",
+			content: `#EXTM3U
+http://stream.example.com/orphan.m3u8
+#EXTINF:-1,News
+#EXTVLCOPT:http-user-agent=Player
+
+http://stream.example.com/news.m3u8
+`,
+			want: []Channel{
+				{Id: "http://stream.example.com/news.m3u8", Name: "News", Url: "http://stream.example.com/news.m3u8"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels, err := ParsePlaylist(tt.content)
+			if err != nil {
+				t.Fatalf("ParsePlaylist() error = %v", err)
+			}
+			if !reflect.DeepEqual(channels, tt.want) {
+				t.Errorf("ParsePlaylist() = %+v, want %+v", channels, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaylistErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"not a playlist", "<html></html>"},
+		{"no channels", "#EXTM3U\n#EXTINF:-1,News\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePlaylist(tt.content); err == nil {
+				t.Error("ParsePlaylist() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestSplitExtInf(t *testing.T) {
+	tests := []struct {
+		info       string
+		attributes map[string]string
+		name       string
+	}{
+		{`-1,News`, map[string]string{}, "News"},
+		{`-1 tvg-id="news" TVG-LOGO="logo.png",News`, map[string]string{"tvg-id": "news", "tvg-logo": "logo.png"}, "News"},
+		{`-1 group-title="A, B",C, D`, map[string]string{"group-title": "A, B"}, "C, D"},
+		{`-1 tvg-id="news"`, map[string]string{"tvg-id": "news"}, ""},
+		{`-1 tvg-id="unterminated,News`, map[string]string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			attributes, name := splitExtInf(tt.info)
+			if !reflect.DeepEqual(attributes, tt.attributes) || name != tt.name {
+				t.Errorf("splitExtInf() = %v, %q, want %v, %q", attributes, name, tt.attributes, tt.name)
+			}
+		})
+	}
+}
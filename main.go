@@ -9,8 +9,11 @@ import (
 	"github.com/fspasovski/pocketstream-app/config"
 	"github.com/fspasovski/pocketstream-app/input"
 	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/notifier"
 	"github.com/fspasovski/pocketstream-app/player"
 	"github.com/fspasovski/pocketstream-app/pocketstream"
+	"github.com/fspasovski/pocketstream-app/recorder"
+	"github.com/fspasovski/pocketstream-app/twitch"
 	"github.com/fspasovski/pocketstream-app/ui"
 	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
@@ -88,7 +91,8 @@ func main() {
 		log.Fatalf("could not create texture: %v", err)
 	}
 
-	mediaPlayer := &player.Player{Cfg: cfg, BroadcasterStreamingUrls: make(map[string]string)}
+	backend := player.NewBackend(cfg)
+	mediaPlayer := &player.Player{Cfg: cfg, Backend: backend, Streams: player.NewStreamCache(cfg.Player.StreamCacheTtl, cfg.Player.StreamRefreshAhead), Exits: make(chan player.Exit, 1), Statuses: make(chan string, 1)}
 	userDataManager := app.LoadUserDataManager()
 
 	app := &app.App{
@@ -106,8 +110,19 @@ func main() {
 		UserDataManager:     userDataManager,
 		PocketstreamService: pocketstream.NewPocketstreamService(cfg),
 		ImageDataService:    common.NewImageDataService(),
+		Provider:            cfg.Providers[0],
+		Recorder:            recorder.NewRecorder(cfg.Recorder),
 	}
 
+	_, app.EmbeddedPlayer = backend.(*player.EmbeddedBackend)
+
+	goLiveNotifier := notifier.NewGoLiveNotifier(
+		cfg.Notifier,
+		func() []*model.Broadcaster { return userDataManager.GetFavoriteBroadcasters(twitch.ProviderName) },
+		app.PocketstreamService.FetchStreams,
+	)
+	goLiveNotifier.Start()
+
 	app.LoadTopStreams()
 
 	for app.Running {
@@ -125,16 +140,52 @@ func main() {
 				keyMapperStrategy := input.GetKeyMapperStrategy(e)
 				if keyMapperStrategy != nil {
 					key := keyMapperStrategy.MapInputToKey(e)
-					if key != input.Unknown {
+					if key != input.Unknown && !ui.HandleGoLiveKey(app, mediaPlayer, key) && !ui.HandlePlaybackKey(app, mediaPlayer, key) {
 						app.State.HandleInput(app, keyMapperStrategy.MapInputToKey(e))
 					}
 				}
 			}
 		}
 
+		select {
+		case exit := <-mediaPlayer.Exits:
+			app.StopChat()
+			if !exit.Background {
+				app.FinishLoading()
+				app.RaiseAppWindow()
+			}
+			app.ShowToast(exit.Reason())
+		case stream := <-goLiveNotifier.GoLives:
+			app.ShowGoLive(stream)
+		case status := <-mediaPlayer.Statuses:
+			if mediaPlayer.IsInForeground() {
+				app.StartLoading(status)
+			} else {
+				app.ShowToast(status)
+			}
+		default:
+		}
+
+		if stats := mediaPlayer.Stats(); stats != nil {
+			app.PlaybackStats = stats.String()
+		} else {
+			app.PlaybackStats = ""
+		}
+
+		app.Videos = mediaPlayer.VideoFrames()
+
+		if nowPlaying := mediaPlayer.NowPlaying(); nowPlaying != nil {
+			app.NowPlaying = nowPlaying.String()
+			app.NowPlayingName = nowPlaying.Name()
+		} else {
+			app.NowPlaying = ""
+			app.NowPlayingName = ""
+		}
+
 		app.Draw()
 	}
 
+	app.Recorder.StopAll()
 	userDataManager.SaveData()
 }
 
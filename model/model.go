@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Stream struct {
 	Id               string
 	Title            string
@@ -7,9 +9,15 @@ type Stream struct {
 	PreviewImageURL  string
 	Broadcaster      *Broadcaster
 	PreviewImageData []byte
+	Cursor           string
+	// Offline is set for channels found while they are not live, LastLiveAt being when
+	// their last broadcast ended when it is known
+	Offline    bool
+	LastLiveAt time.Time
 }
 
 type Broadcaster struct {
+	Provider         string
 	Id               string
 	Login            string
 	DisplayName      string
@@ -25,11 +33,15 @@ func (s *Stream) WithImageData(previewImageBytes []byte, broadcasterImageBytes [
 		PreviewImageURL:  s.PreviewImageURL,
 		Broadcaster:      s.Broadcaster.WithImageData(broadcasterImageBytes),
 		PreviewImageData: previewImageBytes,
+		Cursor:           s.Cursor,
+		Offline:          s.Offline,
+		LastLiveAt:       s.LastLiveAt,
 	}
 }
 
 func (b *Broadcaster) WithImageData(broadcasterImageBytes []byte) *Broadcaster {
 	return &Broadcaster{
+		Provider:         b.Provider,
 		Id:               b.Id,
 		Login:            b.Login,
 		DisplayName:      b.DisplayName,
@@ -37,3 +49,103 @@ func (b *Broadcaster) WithImageData(broadcasterImageBytes []byte) *Broadcaster {
 		ProfileImageData: broadcasterImageBytes,
 	}
 }
+
+type Channel struct {
+	Broadcaster *Broadcaster
+	IsLive      bool
+	Stream      *Stream
+}
+
+// Key identifies the broadcaster across providers.
+func (b *Broadcaster) Key() string {
+	return b.Provider + ":" + b.Login
+}
+
+// StreamDetails is what is known about a live stream beyond what the stream lists show.
+type StreamDetails struct {
+	Stream         *Stream
+	Category       string
+	Tags           []string
+	Language       string
+	StartedAt      time.Time
+	FollowersCount int
+}
+
+// Video is a past broadcast, highlight or clip of a channel, played from its start or
+// any offset rather than live.
+type Video struct {
+	Id string
+	// Type is "archive" for past broadcasts, "highlight" or "clip"
+	Type             string
+	Title            string
+	Duration         time.Duration
+	PublishedAt      time.Time
+	ViewsCount       int
+	PreviewImageURL  string
+	PreviewImageData []byte
+	Broadcaster      *Broadcaster
+}
+
+// ClipPeriod is the time span top clips are ranked over.
+type ClipPeriod int
+
+const (
+	ClipPeriodDay ClipPeriod = iota
+	ClipPeriodWeek
+	ClipPeriodMonth
+	ClipPeriodAll
+)
+
+var ClipPeriods = []ClipPeriod{ClipPeriodDay, ClipPeriodWeek, ClipPeriodMonth, ClipPeriodAll}
+
+func (p ClipPeriod) String() string {
+	switch p {
+	case ClipPeriodDay:
+		return "24h"
+	case ClipPeriodWeek:
+		return "7d"
+	case ClipPeriodMonth:
+		return "30d"
+	}
+	return "All"
+}
+
+type Category struct {
+	Id              string
+	Name            string
+	DisplayName     string
+	ViewersCount    int
+	ChannelsCount   int
+	BoxArtURL       string
+	BoxArtImageData []byte
+}
+
+func (c *Category) WithImageData(boxArtImageBytes []byte) Category {
+	return Category{
+		Id:              c.Id,
+		Name:            c.Name,
+		DisplayName:     c.DisplayName,
+		ViewersCount:    c.ViewersCount,
+		ChannelsCount:   c.ChannelsCount,
+		BoxArtURL:       c.BoxArtURL,
+		BoxArtImageData: boxArtImageBytes,
+	}
+}
+
+// AppendNewStreams appends the streams of the next page, skipping broadcasters that
+// already moved into the existing list while paging.
+func AppendNewStreams(streams []Stream, nextPage []Stream) []Stream {
+	logins := make(map[string]bool, len(streams))
+	for _, stream := range streams {
+		logins[stream.Broadcaster.Login] = true
+	}
+
+	result := make([]Stream, len(streams), len(streams)+len(nextPage))
+	copy(result, streams)
+	for _, stream := range nextPage {
+		if !logins[stream.Broadcaster.Login] {
+			result = append(result, stream)
+		}
+	}
+	return result
+}
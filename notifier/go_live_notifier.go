@@ -0,0 +1,149 @@
+package notifier
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+// GoLiveNotifier polls the favorite broadcasters and reports the ones going from offline
+// to live on GoLives. Which favorites were live is kept in a file, so a restart only
+// reports the ones that went live in the meantime.
+type GoLiveNotifier struct {
+	Config config.NotifierConfig
+	// GoLives receives the streams of favorites that just went live
+	GoLives chan model.Stream
+	// getFavorites returns the favorite broadcasters to check
+	getFavorites func() []*model.Broadcaster
+	// getStreams returns the live streams among the logins, failing rather than
+	// reporting everyone offline when they could not be fetched
+	getStreams func(logins []string) ([]model.Stream, error)
+	mu         sync.Mutex
+	// live is whether each checked login was live on the last poll
+	live map[string]bool
+}
+
+func NewGoLiveNotifier(cfg config.NotifierConfig, getFavorites func() []*model.Broadcaster, getStreams func(logins []string) ([]model.Stream, error)) *GoLiveNotifier {
+	n := &GoLiveNotifier{
+		Config:       cfg,
+		GoLives:      make(chan model.Stream, 10),
+		getFavorites: getFavorites,
+		getStreams:   getStreams,
+		live:         make(map[string]bool),
+	}
+	n.loadState()
+	return n
+}
+
+// Start polls the favorites in the background at the configured interval, unless the
+// notifications are turned off.
+func (n *GoLiveNotifier) Start() {
+	if n.Config.PollInterval <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			n.poll()
+			time.Sleep(n.Config.PollInterval)
+		}
+	}()
+}
+
+// poll reports the favorites live now that were offline on the last poll. Favorites
+// seen for the first time are only recorded, so favoriting a live channel does not
+// announce it.
+func (n *GoLiveNotifier) poll() {
+	favorites := make(map[string]*model.Broadcaster)
+	for _, broadcaster := range n.getFavorites() {
+		favorites[broadcaster.Login] = broadcaster
+	}
+	if len(favorites) == 0 {
+		return
+	}
+
+	logins := make([]string, 0, len(favorites))
+	for login := range favorites {
+		logins = append(logins, login)
+	}
+
+	streams, err := n.getStreams(logins)
+	if err != nil {
+		log.Printf("An error occurred while checking favorites going live: %v", err)
+		return
+	}
+
+	liveStreams := make(map[string]model.Stream, len(streams))
+	for _, stream := range streams {
+		liveStreams[stream.Broadcaster.Login] = stream
+	}
+
+	n.mu.Lock()
+	live := make(map[string]bool, len(favorites))
+	changed := len(n.live) != len(favorites)
+	for login, broadcaster := range favorites {
+		stream, isLive := liveStreams[login]
+		wasLive, known := n.live[login]
+		live[login] = isLive
+		changed = changed || !known || wasLive != isLive
+
+		if known && !wasLive && isLive {
+			// The API only knows the login, the favorite has the name to show
+			stream.Broadcaster.DisplayName = broadcaster.DisplayName
+			stream.Broadcaster.ProfileImageURL = broadcaster.ProfileImageURL
+			select {
+			case n.GoLives <- stream:
+			default:
+			}
+		}
+	}
+	n.live = live
+	n.mu.Unlock()
+
+	if changed {
+		n.saveState(live)
+	}
+}
+
+func (n *GoLiveNotifier) loadState() {
+	data, err := os.ReadFile(n.Config.StatePath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read live state file at: %v, err: %v", n.Config.StatePath, err)
+		return
+	}
+
+	var live map[string]bool
+	if err := json.Unmarshal(data, &live); err != nil {
+		log.Printf("Failed to parse live state file at: %v, err: %v", n.Config.StatePath, err)
+		return
+	}
+	if live != nil {
+		n.live = live
+	}
+}
+
+func (n *GoLiveNotifier) saveState(live map[string]bool) {
+	data, err := json.MarshalIndent(live, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal live state: %v", err)
+		return
+	}
+
+	tempPath := n.Config.StatePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		log.Printf("Failed to write temporary live state file: %v", err)
+		return
+	}
+
+	if err := os.Rename(tempPath, n.Config.StatePath); err != nil {
+		log.Printf("Failed to rename temporary live state file: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+// testPoll is one poll of the favorites, with the logins live at the time and the ones
+// expected to be announced
+type testPoll struct {
+	favorites []string
+	live      []string
+	err       error
+	announced []string
+}
+
+func TestGoLiveNotifierPoll(t *testing.T) {
+	tests := []struct {
+		name string
+		// state is the content of the state file before the notifier starts
+		state map[string]bool
+		polls []testPoll
+		// saved is the content of the state file after the last poll
+		saved map[string]bool
+	}{
+		{
+			name: "first seen favorites are not announced",
+			polls: []testPoll{
+				{favorites: []string{"alice", "bob"}, live: []string{"alice"}},
+				{favorites: []string{"alice", "bob"}, live: []string{"alice", "bob"}, announced: []string{"bob"}},
+			},
+			saved: map[string]bool{"alice": true, "bob": true},
+		},
+		{
+			name: "favorites staying live are announced once",
+			polls: []testPoll{
+				{favorites: []string{"alice"}},
+				{favorites: []string{"alice"}, live: []string{"alice"}, announced: []string{"alice"}},
+				{favorites: []string{"alice"}, live: []string{"alice"}},
+				{favorites: []string{"alice"}},
+				{favorites: []string{"alice"}, live: []string{"alice"}, announced: []string{"alice"}},
+			},
+			saved: map[string]bool{"alice": true},
+		},
+		{
+			name:  "favorites going live during a restart are announced",
+			state: map[string]bool{"alice": false, "bob": true},
+			polls: []testPoll{
+				{favorites: []string{"alice", "bob"}, live: []string{"alice", "bob"}, announced: []string{"alice"}},
+			},
+			saved: map[string]bool{"alice": true, "bob": true},
+		},
+		{
+			name: "removed favorites are forgotten",
+			polls: []testPoll{
+				{favorites: []string{"alice", "bob"}},
+				{favorites: []string{"alice"}, live: []string{"alice", "bob"}, announced: []string{"alice"}},
+				{favorites: []string{"alice", "bob"}, live: []string{"alice", "bob"}},
+			},
+			saved: map[string]bool{"alice": true, "bob": true},
+		},
+		{
+			name: "failed fetches keep the last state",
+			polls: []testPoll{
+				{favorites: []string{"alice"}},
+				{favorites: []string{"alice"}, err: errors.New("network is unreachable")},
+				{favorites: []string{"alice"}, live: []string{"alice"}, announced: []string{"alice"}},
+			},
+			saved: map[string]bool{"alice": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "liveState.json")
+			if tt.state != nil {
+				data, _ := json.Marshal(tt.state)
+				if err := os.WriteFile(statePath, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var poll testPoll
+			n := NewGoLiveNotifier(
+				config.NotifierConfig{StatePath: statePath},
+				func() []*model.Broadcaster {
+					favorites := make([]*model.Broadcaster, 0, len(poll.favorites))
+					for _, login := range poll.favorites {
+						favorites = append(favorites, &model.Broadcaster{Login: login, DisplayName: "Display " + login})
+					}
+					return favorites
+				},
+				func(logins []string) ([]model.Stream, error) {
+					if poll.err != nil {
+						return nil, poll.err
+					}
+					streams := make([]model.Stream, 0, len(poll.live))
+					for _, login := range poll.live {
+						streams = append(streams, model.Stream{Title: login + " stream", Broadcaster: &model.Broadcaster{Login: login}})
+					}
+					return streams, nil
+				},
+			)
+
+			for i := range tt.polls {
+				poll = tt.polls[i]
+				n.poll()
+
+				announced := make([]string, 0)
+				for len(n.GoLives) > 0 {
+					stream := <-n.GoLives
+					if stream.Broadcaster.DisplayName != "Display "+stream.Broadcaster.Login {
+						t.Errorf("poll %d announced %s as %q, want the display name of the favorite", i, stream.Broadcaster.Login, stream.Broadcaster.DisplayName)
+					}
+					announced = append(announced, stream.Broadcaster.Login)
+				}
+				if len(announced) != len(poll.announced) || (len(announced) > 0 && !reflect.DeepEqual(announced, poll.announced)) {
+					t.Errorf("poll %d announced %v, want %v", i, announced, poll.announced)
+				}
+			}
+
+			data, err := os.ReadFile(statePath)
+			if err != nil {
+				t.Fatalf("reading the saved state: %v", err)
+			}
+			var saved map[string]bool
+			if err := json.Unmarshal(data, &saved); err != nil {
+				t.Fatalf("parsing the saved state: %v", err)
+			}
+			if !reflect.DeepEqual(saved, tt.saved) {
+				t.Errorf("saved state = %v, want %v", saved, tt.saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/video"
+)
+
+const (
+	FfplayBackendName   = "ffplay"
+	MpvBackendName      = "mpv"
+	EmbeddedBackendName = "embedded"
+)
+
+var ErrUnsupported = errors.New("not supported by the player backend")
+
+// PlayerBackend starts a player process for a stream url and, when the player allows
+// it, controls the running playback.
+type PlayerBackend interface {
+	Name() string
+	// Command prepares the player process for the stream, the caller starts and owns it
+	Command(streamUrl string, geometry Geometry) *exec.Cmd
+	// Close releases whatever the backend holds for the last started process
+	Close()
+	TogglePause() error
+	ToggleMute() error
+	SetVolume(volume int) error
+	// Seek moves the playback by the given number of seconds within the buffered stream
+	Seek(seconds float64) error
+	State() (*PlaybackState, error)
+	// ShowText shows a message on top of the video for a moment
+	ShowText(text string) error
+}
+
+// Geometry is the size of the player window and, when Positioned is set, its
+// top left corner on screen. AudioOnly players open no window at all, Muted players
+// leave out the audio. Videos start StartAt into the recording.
+type Geometry struct {
+	X          int
+	Y          int
+	Width      int
+	Height     int
+	Positioned bool
+	AudioOnly  bool
+	Muted      bool
+	StartAt    time.Duration
+}
+
+type PlaybackState struct {
+	Paused bool
+	Muted  bool
+	Volume int
+	// Position is the playback time in seconds since the player started
+	Position float64
+	// BufferedAhead is the number of seconds cached after the current position
+	BufferedAhead float64
+	Buffering     bool
+}
+
+// NewBackend creates the player backend selected in the config, ffplay being the default.
+func NewBackend(cfg *config.Config) PlayerBackend {
+	return newBackend(cfg, cfg.Player.MpvIpcSocketPath)
+}
+
+// newViewBackend creates a backend of its own for a view of a multi-view, each mpv
+// instance listening on a socket numbered after the view.
+func newViewBackend(cfg *config.Config, index int) PlayerBackend {
+	return newBackend(cfg, fmt.Sprintf("%s.%d", cfg.Player.MpvIpcSocketPath, index))
+}
+
+func newBackend(cfg *config.Config, mpvIpcSocketPath string) PlayerBackend {
+	switch cfg.Player.Backend {
+	case MpvBackendName:
+		return &MpvBackend{IpcSocketPath: mpvIpcSocketPath}
+	case EmbeddedBackendName:
+		return &EmbeddedBackend{AudioOutput: cfg.Player.AudioOutput, AudioDevice: cfg.Player.AudioDevice, Frames: &video.FrameBuffer{}}
+	default:
+		return &FfplayBackend{}
+	}
+}
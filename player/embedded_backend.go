@@ -0,0 +1,162 @@
+package player
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/video"
+)
+
+// embeddedStallThreshold is how long the decoder may go without a frame before the
+// playback counts as buffering.
+const embeddedStallThreshold = 2 * time.Second
+
+// EmbeddedBackend decodes streams with ffmpeg into raw frames the app draws in its own
+// window, so chat and overlays are drawn on top of the video and the app never loses
+// focus. The audio is played by ffmpeg on the configured output device.
+type EmbeddedBackend struct {
+	// AudioOutput is the ffmpeg output device format, e.g. "alsa" or "pulse"
+	AudioOutput string
+	AudioDevice string
+	Frames      *video.FrameBuffer
+	mu          sync.Mutex
+	cmd         *exec.Cmd
+	decoding    bool
+	paused      bool
+	lastFrameAt time.Time
+}
+
+func (b *EmbeddedBackend) Name() string {
+	return EmbeddedBackendName
+}
+
+func (b *EmbeddedBackend) Command(streamUrl string, geometry Geometry) *exec.Cmd {
+	b.Close()
+
+	args := []string{"-loglevel", "error"}
+	if geometry.StartAt > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.0f", geometry.StartAt.Seconds()))
+	}
+	// Read the input at its native rate, so a recording is not decoded at once
+	args = append(args, "-re", "-i", streamUrl)
+
+	var cmd *exec.Cmd
+	if geometry.AudioOnly {
+		args = append(args, "-vn", "-f", b.AudioOutput, b.AudioDevice)
+		cmd = exec.Command("ffmpeg", args...)
+	} else {
+		// YUV 4:2:0 frames need an even size
+		width, height := geometry.Width&^1, geometry.Height&^1
+		args = append(args,
+			"-map", "0:v:0",
+			"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", width, height, width, height),
+			"-pix_fmt", "yuv420p",
+			"-f", "rawvideo", "pipe:1",
+		)
+		if !geometry.Muted {
+			args = append(args, "-map", "0:a:0?", "-f", b.AudioOutput, b.AudioDevice)
+		}
+		cmd = exec.Command("ffmpeg", args...)
+
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Printf("Failed to read frames from ffmpeg: %v", err)
+		} else {
+			b.Frames.Start(geometry.X, geometry.Y, width, height, geometry.Positioned)
+			go b.readFrames(stdout, video.FrameSize(width, height))
+		}
+	}
+
+	b.mu.Lock()
+	b.cmd = cmd
+	b.decoding = !geometry.AudioOnly
+	b.lastFrameAt = time.Now()
+	b.mu.Unlock()
+	return cmd
+}
+
+// readFrames hands the decoded frames over to the app until ffmpeg exits.
+func (b *EmbeddedBackend) readFrames(stdout io.Reader, frameSize int) {
+	frame := make([]byte, frameSize)
+	for {
+		if _, err := io.ReadFull(stdout, frame); err != nil {
+			return
+		}
+		b.Frames.Put(frame)
+
+		b.mu.Lock()
+		b.lastFrameAt = time.Now()
+		b.mu.Unlock()
+	}
+}
+
+func (b *EmbeddedBackend) Close() {
+	b.Frames.Stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cmd = nil
+	b.decoding = false
+	b.paused = false
+}
+
+// TogglePause suspends the decoder, which holds back both the video and the audio.
+func (b *EmbeddedBackend) TogglePause() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cmd == nil || b.cmd.Process == nil {
+		return nil
+	}
+
+	signal := syscall.SIGSTOP
+	if b.paused {
+		signal = syscall.SIGCONT
+	}
+	pgid, err := syscall.Getpgid(b.cmd.Process.Pid)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Kill(-pgid, signal); err != nil {
+		return err
+	}
+
+	b.paused = !b.paused
+	b.lastFrameAt = time.Now()
+	return nil
+}
+
+func (b *EmbeddedBackend) ToggleMute() error {
+	return ErrUnsupported
+}
+
+func (b *EmbeddedBackend) SetVolume(volume int) error {
+	return ErrUnsupported
+}
+
+func (b *EmbeddedBackend) Seek(seconds float64) error {
+	return ErrUnsupported
+}
+
+// State reports the video playback as buffering when no frame was decoded for a while.
+func (b *EmbeddedBackend) State() (*PlaybackState, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &PlaybackState{
+		Paused:    b.paused,
+		Volume:    100,
+		Buffering: b.decoding && !b.paused && time.Since(b.lastFrameAt) > embeddedStallThreshold,
+	}, nil
+}
+
+// ShowText is left to the app, which draws its own OSD on top of the video.
+func (b *EmbeddedBackend) ShowText(text string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,60 @@
+package player
+
+import (
+	"strings"
+	"sync"
+)
+
+const stderrTailSize = 4096
+
+// Exit describes a player process that ended on its own, without Stop being called.
+type Exit struct {
+	// Err is the error the process exited with, nil on a clean exit
+	Err        error
+	StderrTail string
+	// Background is set when the player had no window, the app was on screen all along
+	Background bool
+}
+
+// Reason returns a short explanation of why the playback ended, preferring the last
+// line the player printed over the bare exit status.
+func (e *Exit) Reason() string {
+	if e.Err == nil {
+		return "Stream ended"
+	}
+
+	lines := strings.Split(strings.TrimSpace(e.StderrTail), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		// ffplay redraws its status line with carriage returns
+		line := strings.TrimSpace(lines[i][strings.LastIndex(lines[i], "\r")+1:])
+		if line != "" {
+			return "Playback stopped: " + line
+		}
+	}
+
+	return "Playback stopped: " + e.Err.Error()
+}
+
+// tailBuffer keeps the last bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > stderrTailSize {
+		b.data = b.data[len(b.data)-stderrTailSize:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.data)
+}
@@ -0,0 +1,70 @@
+package player
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// FfplayBackend plays streams with ffplay, which offers no way of controlling the
+// playback once started.
+type FfplayBackend struct{}
+
+func (b *FfplayBackend) Name() string {
+	return FfplayBackendName
+}
+
+func (b *FfplayBackend) Command(streamUrl string, geometry Geometry) *exec.Cmd {
+	args := make([]string, 0)
+	if geometry.StartAt > 0 {
+		args = append(args, "-ss", strconv.FormatFloat(geometry.StartAt.Seconds(), 'f', 0, 64))
+	}
+
+	if geometry.AudioOnly {
+		return exec.Command("ffplay", append(args, "-nodisp", "-autoexit", "-loglevel", "error", streamUrl)...)
+	}
+
+	if geometry.Muted {
+		args = append(args, "-an")
+	}
+	if geometry.Positioned {
+		args = append(args, "-left", strconv.Itoa(geometry.X), "-top", strconv.Itoa(geometry.Y))
+	}
+
+	args = append(args,
+		"-vf", fmt.Sprintf("scale=%d:%d", geometry.Width, geometry.Height),
+		"-window_title", "Pocketstream",
+		"-autoexit",
+		"-x", strconv.Itoa(geometry.Width),
+		"-y", strconv.Itoa(geometry.Height),
+		streamUrl,
+	)
+
+	return exec.Command("ffplay", args...)
+}
+
+func (b *FfplayBackend) Close() {}
+
+func (b *FfplayBackend) TogglePause() error {
+	return ErrUnsupported
+}
+
+func (b *FfplayBackend) ToggleMute() error {
+	return ErrUnsupported
+}
+
+func (b *FfplayBackend) SetVolume(volume int) error {
+	return ErrUnsupported
+}
+
+func (b *FfplayBackend) Seek(seconds float64) error {
+	return ErrUnsupported
+}
+
+func (b *FfplayBackend) State() (*PlaybackState, error) {
+	return nil, ErrUnsupported
+}
+
+func (b *FfplayBackend) ShowText(text string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,222 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	mpvConnectTimeout = 5 * time.Second
+	mpvReplyTimeout   = 2 * time.Second
+)
+
+const mpvOsdDuration = 1500
+
+var errMpvPropertyUnavailable = errors.New("property unavailable")
+
+// MpvBackend plays streams with mpv and controls it through its JSON IPC socket.
+type MpvBackend struct {
+	IpcSocketPath string
+	mu            sync.Mutex
+	conn          net.Conn
+	reader        *bufio.Reader
+	requestId     int
+}
+
+type mpvRequest struct {
+	Command   []any `json:"command"`
+	RequestId int   `json:"request_id"`
+}
+
+// mpvReply is either the reply to a request or an event, events carry no request id
+type mpvReply struct {
+	Event     string          `json:"event"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	RequestId int             `json:"request_id"`
+}
+
+func (b *MpvBackend) Name() string {
+	return MpvBackendName
+}
+
+func (b *MpvBackend) Command(streamUrl string, geometry Geometry) *exec.Cmd {
+	b.Close()
+	os.Remove(b.IpcSocketPath)
+
+	args := []string{
+		"--input-ipc-server=" + b.IpcSocketPath,
+		"--msg-level=all=error",
+		// Keep what was already played so the live stream can be rewound
+		"--cache=yes",
+		"--demuxer-seekable-cache=yes",
+		"--demuxer-max-back-bytes=64MiB",
+	}
+
+	if geometry.AudioOnly {
+		args = append(args, "--no-video", "--force-window=no")
+	} else {
+		windowGeometry := fmt.Sprintf("%dx%d", geometry.Width, geometry.Height)
+		if geometry.Positioned {
+			windowGeometry += fmt.Sprintf("+%d+%d", geometry.X, geometry.Y)
+		}
+		args = append(args, "--geometry="+windowGeometry, "--title=Pocketstream", "--no-border", "--force-window=immediate")
+	}
+	if geometry.Muted {
+		args = append(args, "--mute=yes")
+	}
+	if geometry.StartAt > 0 {
+		args = append(args, fmt.Sprintf("--start=%.0f", geometry.StartAt.Seconds()))
+	}
+
+	return exec.Command("mpv", append(args, streamUrl)...)
+}
+
+func (b *MpvBackend) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+		b.reader = nil
+	}
+}
+
+func (b *MpvBackend) TogglePause() error {
+	_, err := b.command("cycle", "pause")
+	return err
+}
+
+func (b *MpvBackend) ToggleMute() error {
+	_, err := b.command("cycle", "mute")
+	return err
+}
+
+func (b *MpvBackend) SetVolume(volume int) error {
+	_, err := b.command("set_property", "volume", volume)
+	return err
+}
+
+func (b *MpvBackend) Seek(seconds float64) error {
+	_, err := b.command("seek", seconds, "relative")
+	return err
+}
+
+func (b *MpvBackend) State() (*PlaybackState, error) {
+	state := &PlaybackState{}
+
+	var volume float64
+	properties := []struct {
+		name  string
+		value any
+	}{
+		{"pause", &state.Paused},
+		{"mute", &state.Muted},
+		{"volume", &volume},
+		{"time-pos", &state.Position},
+		{"demuxer-cache-duration", &state.BufferedAhead},
+		{"paused-for-cache", &state.Buffering},
+	}
+
+	for _, property := range properties {
+		data, err := b.command("get_property", property.name)
+		if err != nil {
+			// Properties like time-pos are unavailable until playback starts
+			if errors.Is(err, errMpvPropertyUnavailable) {
+				continue
+			}
+			return nil, err
+		}
+		if err := json.Unmarshal(data, property.value); err != nil {
+			return nil, err
+		}
+	}
+
+	state.Volume = int(volume + 0.5)
+	return state, nil
+}
+
+func (b *MpvBackend) ShowText(text string) error {
+	_, err := b.command("show-text", text, mpvOsdDuration)
+	return err
+}
+
+// command sends a command to mpv and waits for its reply, skipping any events
+// mpv sends in the meantime.
+func (b *MpvBackend) command(args ...any) (json.RawMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.connect(); err != nil {
+		return nil, err
+	}
+
+	b.requestId++
+	request, err := json.Marshal(mpvRequest{Command: args, RequestId: b.requestId})
+	if err != nil {
+		return nil, err
+	}
+
+	b.conn.SetDeadline(time.Now().Add(mpvReplyTimeout))
+	if _, err := b.conn.Write(append(request, '\n')); err != nil {
+		b.disconnect()
+		return nil, err
+	}
+
+	for {
+		line, err := b.reader.ReadBytes('\n')
+		if err != nil {
+			b.disconnect()
+			return nil, err
+		}
+
+		var reply mpvReply
+		if err := json.Unmarshal(line, &reply); err != nil || reply.Event != "" || reply.RequestId != b.requestId {
+			continue
+		}
+
+		switch reply.Error {
+		case "success":
+			return reply.Data, nil
+		case "property unavailable":
+			return nil, errMpvPropertyUnavailable
+		default:
+			return nil, fmt.Errorf("mpv command %v failed: %s", args[0], reply.Error)
+		}
+	}
+}
+
+// connect opens the IPC socket, waiting for mpv to create it after a fresh start.
+func (b *MpvBackend) connect() error {
+	if b.conn != nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(mpvConnectTimeout)
+	for {
+		conn, err := net.Dial("unix", b.IpcSocketPath)
+		if err == nil {
+			b.conn = conn
+			b.reader = bufio.NewReader(conn)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("could not connect to mpv: %w", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (b *MpvBackend) disconnect() {
+	b.conn.Close()
+	b.conn = nil
+	b.reader = nil
+}
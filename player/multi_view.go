@@ -0,0 +1,279 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"syscall"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/hlsproxy"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+const MaxMultiViewStreams = 4
+
+// view is one of the streams of a multi-view, played by a backend of its own in its
+// part of the screen. Only one view at a time plays its audio.
+type view struct {
+	broadcaster *model.Broadcaster
+	variant     hls.Variant
+	geometry    Geometry
+	backend     PlayerBackend
+	process     *exec.Cmd
+	waiting     chan struct{}
+}
+
+// MultiViewLayout splits the player area between count streams, two side by side or up
+// to four in a grid, each keeping a 16:9 picture centered in its cell.
+func MultiViewLayout(cfg config.PlayerConfig, count int) []Geometry {
+	columns, rows := 2, 1
+	if count > 2 {
+		rows = 2
+	}
+
+	cellWidth := cfg.StreamWidth / columns
+	cellHeight := cfg.StreamHeight / rows
+	width := min(cellWidth, cellHeight*16/9)
+	height := width * 9 / 16
+
+	layout := make([]Geometry, count)
+	for i := range layout {
+		layout[i] = Geometry{
+			X:          (i%columns)*cellWidth + (cellWidth-width)/2,
+			Y:          (i/columns)*cellHeight + (cellHeight-height)/2,
+			Width:      width,
+			Height:     height,
+			Positioned: true,
+		}
+	}
+	return layout
+}
+
+// PlayMultiView plays the streams of the broadcasters next to each other, replacing
+// whatever is playing. Each stream is played in the quality closest to the size of its
+// view, with only the first one audible.
+func (p *Player) PlayMultiView(broadcasters []*model.Broadcaster) error {
+	if len(broadcasters) < 2 || len(broadcasters) > MaxMultiViewStreams {
+		return fmt.Errorf("a multi-view needs 2 to %d streams", MaxMultiViewStreams)
+	}
+
+	p.Stop()
+
+	p.mu.Lock()
+	p.stopped = make(chan struct{})
+	p.mu.Unlock()
+
+	layout := MultiViewLayout(p.Cfg.Player, len(broadcasters))
+	views := make([]*view, len(broadcasters))
+	for i, broadcaster := range broadcasters {
+		selection, err := p.GetStreamingUrl(broadcaster, []string{fmt.Sprintf("%dp", layout[i].Height)})
+		if err != nil {
+			return fmt.Errorf("%s: %w", broadcaster.Login, err)
+		}
+
+		geometry := layout[i]
+		geometry.Muted = i > 0
+		views[i] = &view{broadcaster: broadcaster, variant: selection.Variant, geometry: geometry, backend: newViewBackend(p.Cfg, i+1)}
+	}
+
+	p.mu.Lock()
+	p.views = views
+	p.audioView = 0
+	p.mu.Unlock()
+
+	for _, v := range views {
+		if err := p.startView(v); err != nil {
+			p.Stop()
+			return fmt.Errorf("%s: %w", v.broadcaster.Login, err)
+		}
+	}
+	return nil
+}
+
+// IsMultiView reports whether a multi-view is playing.
+func (p *Player) IsMultiView() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.views) > 0
+}
+
+// SwitchAudio moves the audio over to the next running view in the given direction and
+// returns the broadcaster now heard.
+func (p *Player) SwitchAudio(direction int) (*model.Broadcaster, error) {
+	p.mu.Lock()
+	views, current := p.views, p.audioView
+	next := current
+	for i := 1; i < len(views); i++ {
+		candidate := ((current+direction*i)%len(views) + len(views)) % len(views)
+		if views[candidate].process != nil {
+			next = candidate
+			break
+		}
+	}
+	p.audioView = next
+	p.mu.Unlock()
+
+	if len(views) == 0 {
+		return nil, nil
+	}
+	if next == current {
+		return views[current].broadcaster, nil
+	}
+
+	if err := p.setViewMuted(views[current], true); err != nil {
+		return nil, err
+	}
+	if err := p.setViewMuted(views[next], false); err != nil {
+		return nil, err
+	}
+	return views[next].broadcaster, nil
+}
+
+// setViewMuted mutes or unmutes a running view through its backend, or restarts it
+// without or with audio when the backend cannot be controlled.
+func (p *Player) setViewMuted(v *view, muted bool) error {
+	p.mu.Lock()
+	if v.geometry.Muted == muted {
+		p.mu.Unlock()
+		return nil
+	}
+	v.geometry.Muted = muted
+	running := v.process != nil
+	p.mu.Unlock()
+
+	if !running {
+		return nil
+	}
+
+	err := v.backend.ToggleMute()
+	if !errors.Is(err, ErrUnsupported) {
+		return err
+	}
+	return p.restartView(v)
+}
+
+func (p *Player) restartView(v *view) error {
+	p.mu.Lock()
+	cmd, waiting := v.process, v.waiting
+	v.process = nil
+	p.mu.Unlock()
+
+	if cmd != nil {
+		kill(cmd)
+		<-waiting
+		v.backend.Close()
+	}
+	return p.startView(v)
+}
+
+// startView starts the player of a view, the view playing the stream without the
+// reconnects of a single stream.
+func (p *Player) startView(v *view) error {
+	streamUrl := v.variant.Url
+	session, err := p.startSession(streamUrl)
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		streamUrl = session.Url()
+	}
+
+	p.mu.Lock()
+	geometry := v.geometry
+	p.mu.Unlock()
+
+	stderr := &tailBuffer{}
+	cmd := v.backend.Command(streamUrl, geometry)
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		log.Printf("%s exited with error: %v", v.backend.Name(), err)
+		closeSession(session)
+		return err
+	}
+
+	waiting := make(chan struct{})
+	p.mu.Lock()
+	if isClosed(p.stopped) {
+		p.mu.Unlock()
+		kill(cmd)
+		cmd.Wait()
+		closeSession(session)
+		return errStopped
+	}
+	v.process = cmd
+	v.waiting = waiting
+	p.mu.Unlock()
+
+	go p.waitView(v, cmd, session, stderr, waiting)
+	return nil
+}
+
+// waitView reaps the player of a view. The view is left empty when its stream ends,
+// the multi-view is reported on Exits once all of them ended.
+func (p *Player) waitView(v *view, cmd *exec.Cmd, session *hlsproxy.Session, stderr *tailBuffer, waiting chan struct{}) {
+	err := cmd.Wait()
+	closeSession(session)
+	close(waiting)
+
+	p.mu.Lock()
+	if v.process != cmd {
+		// Stopped or restarted
+		p.mu.Unlock()
+		return
+	}
+	v.process = nil
+	running := 0
+	for _, other := range p.views {
+		if other.process != nil {
+			running++
+		}
+	}
+	if running == 0 {
+		p.views = nil
+	}
+	p.mu.Unlock()
+
+	v.backend.Close()
+	exit := Exit{Err: err, StderrTail: stderr.String()}
+	log.Printf("%s view of %s exited: %s", v.backend.Name(), v.broadcaster.Key(), exit.Reason())
+
+	if running > 0 {
+		return
+	}
+
+	select {
+	case p.Exits <- exit:
+	default:
+	}
+}
+
+// detachViews takes the views out of the player, with p.mu held, and returns a function
+// stopping their players.
+func (p *Player) detachViews() func() {
+	views := p.views
+	p.views = nil
+
+	processes := make([]*exec.Cmd, len(views))
+	waitings := make([]chan struct{}, len(views))
+	for i, v := range views {
+		processes[i], waitings[i] = v.process, v.waiting
+		v.process = nil
+	}
+
+	return func() {
+		for i, v := range views {
+			if processes[i] == nil {
+				continue
+			}
+			kill(processes[i])
+			<-waitings[i]
+			v.backend.Close()
+		}
+	}
+}
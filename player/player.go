@@ -1,60 +1,713 @@
 package player
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os/exec"
-	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/hlsproxy"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/video"
 )
 
+const stallCheckInterval = 2 * time.Second
+
+// videoEndMargin keeps seeks short of the end of a video, so it does not end right away
+const videoEndMargin = 5 * time.Second
+
+var errStopped = errors.New("playback stopped")
+
 type Player struct {
-	Cfg                      *config.Config
-	Process                  *exec.Cmd
-	BroadcasterStreamingUrls map[string]string
-}
-
-func (p *Player) Play(broadcasterLogin string) error {
-	streamUrl, exists := p.BroadcasterStreamingUrls[broadcasterLogin]
-	if !exists {
-		streamUrl, _ = p.Cfg.TwitchService.GetStreamingUrl(broadcasterLogin)
-		p.BroadcasterStreamingUrls[broadcasterLogin] = streamUrl
-	}
-	var cmd *exec.Cmd
-	cmd = exec.Command("ffplay",
-		"-vf", fmt.Sprintf("scale=%d:%d", p.Cfg.Player.StreamWidth, p.Cfg.Player.StreamHeight),
-		"-window_title", "Pocketstream",
-		"-autoexit",
-		"-x", strconv.Itoa(p.Cfg.Player.StreamWidth),
-		"-y", strconv.Itoa(p.Cfg.Player.StreamHeight),
-		streamUrl,
-	)
+	Cfg      *config.Config
+	Backend  PlayerBackend
+	Process  *exec.Cmd
+	Streams  *StreamCache
+	ShowChat bool
+	// Exits receives the players that ended without Stop being called and could not be reconnected
+	Exits chan Exit
+	// Statuses receives the playback status while reconnecting, e.g. "Reconnecting (2/5)..."
+	Statuses chan string
+	mu       sync.Mutex
+	waiting  chan struct{}
+	// stopped is closed by Stop, it cancels a pending reconnect
+	stopped      chan struct{}
+	session      *hlsproxy.Session
+	broadcaster  *model.Broadcaster
+	variant      hls.Variant
+	startedAt    time.Time
+	preferences  []string
+	reconnecting bool
+	// starting is set while a proxy session waits for the stream, e.g. for an ad break to end
+	starting bool
+	// views are the streams of a multi-view, played instead of Process
+	views     []*view
+	audioView int
+	// video is the past broadcast, highlight or recording being played, started
+	// startOffset into it
+	video       *model.Video
+	startOffset time.Duration
+	pausedAt    time.Time
+	pausedFor   time.Duration
+}
+
+// NowPlaying describes the stream playing in the background, behind the app.
+type NowPlaying struct {
+	Broadcaster *model.Broadcaster
+	Variant     hls.Variant
+	StartedAt   time.Time
+}
+
+func (p *Player) Play(broadcaster *model.Broadcaster) (*hls.Selection, error) {
+	selection, err := p.GetStreamingUrl(broadcaster, p.Cfg.Player.StreamQualityPreferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return selection, p.PlayVariant(broadcaster, selection.Variant)
+}
+
+// GetStreamingUrl picks the variant of the broadcaster stream closest to the given
+// quality preferences.
+func (p *Player) GetStreamingUrl(broadcaster *model.Broadcaster, preferences []string) (*hls.Selection, error) {
+	playlist, err := p.GetStreamPlaylist(broadcaster)
+	if err != nil {
+		return nil, err
+	}
+
+	return hls.SelectVariant(playlist.Variants, preferences)
+}
+
+// GetStreamPlaylist returns the cached master playlist of the broadcaster stream, or
+// fetches it through the broadcaster provider.
+func (p *Player) GetStreamPlaylist(broadcaster *model.Broadcaster) (*hls.MasterPlaylist, error) {
+	streamProvider, configured := p.Cfg.Provider(broadcaster.Provider)
+	if !configured {
+		return nil, fmt.Errorf("provider %s is not configured", broadcaster.Provider)
+	}
+
+	return p.Streams.Get(broadcaster.Key(), func() (*hls.MasterPlaylist, error) {
+		return streamProvider.GetStreamPlaylist(broadcaster.Login)
+	})
+}
+
+// PlayVariant plays a variant of the broadcaster stream, replacing whatever is playing.
+// The broadcaster is kept to reconnect to the same quality when the stream drops.
+// Audio only variants play without a window, leaving the app usable.
+func (p *Player) PlayVariant(broadcaster *model.Broadcaster, variant hls.Variant) error {
+	p.Stop()
+
+	p.mu.Lock()
+	p.broadcaster = broadcaster
+	p.preferences = reconnectPreferences(variant, p.Cfg.Player.StreamQualityPreferences)
+	p.start(nil, 0)
+	p.mu.Unlock()
+
+	return p.playUrl(variant)
+}
+
+// PlayVideo plays a variant of a past broadcast, highlight or recording from the given
+// offset, replacing whatever is playing. Videos are played as they are, without the
+// HLS proxy following live playlists and without reconnecting.
+func (p *Player) PlayVideo(video *model.Video, variant hls.Variant, offset time.Duration) error {
+	p.Stop()
+
+	p.mu.Lock()
+	p.broadcaster = nil
+	p.preferences = nil
+	p.start(video, offset)
+	p.mu.Unlock()
+
+	return p.playUrl(variant)
+}
+
+// start resets the playback state for a new stream or video, with p.mu held.
+func (p *Player) start(video *model.Video, offset time.Duration) {
+	p.stopped = make(chan struct{})
+	p.startedAt = time.Now()
+	p.video = video
+	p.startOffset = offset
+	p.pausedAt = time.Time{}
+	p.pausedFor = 0
+}
+
+// PlayAudioOnly plays the audio only rendition of the broadcaster stream in the background.
+func (p *Player) PlayAudioOnly(broadcaster *model.Broadcaster) error {
+	playlist, err := p.GetStreamPlaylist(broadcaster)
+	if err != nil {
+		return err
+	}
+
+	for _, variant := range playlist.Variants {
+		if variant.IsAudioOnly {
+			return p.PlayVariant(broadcaster, variant)
+		}
+	}
+	return fmt.Errorf("no audio only rendition for %s", broadcaster.Login)
+}
+
+// Broadcaster returns the broadcaster being played, nil for recordings or when nothing plays.
+func (p *Player) Broadcaster() *model.Broadcaster {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Process == nil && !p.reconnecting {
+		return nil
+	}
+	return p.broadcaster
+}
+
+func (p *Player) playUrl(variant hls.Variant) error {
+	streamUrl := variant.Url
+	geometry := Geometry{Width: p.Cfg.Player.StreamWidth, Height: p.Cfg.Player.StreamHeight, AudioOnly: variant.IsAudioOnly}
+
+	// Leave room for the chat panel on the right and keep a 16:9 picture
+	if p.ShowChat {
+		geometry.Width -= int(p.Cfg.Chat.PanelWidth)
+		geometry.Height = geometry.Width * 9 / 16
+		geometry.Y = int(p.Cfg.UI.HeaderHeight)
+		geometry.Positioned = true
+	}
+
+	p.mu.Lock()
+	video := p.video
+	geometry.StartAt = p.startOffset
+	p.mu.Unlock()
+
+	var session *hlsproxy.Session
+	if video == nil {
+		var err error
+		if session, err = p.startSession(streamUrl); err != nil {
+			return err
+		}
+	}
+	if session != nil {
+		streamUrl = session.Url()
+	}
+
+	stderr := &tailBuffer{}
+	cmd := p.Backend.Command(streamUrl, geometry)
+	cmd.Stderr = stderr
+	// Run the player in its own process group, so stopping it also stops its children
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	if err := cmd.Start(); err != nil {
-		log.Printf("ffplay exited with error: %v", err)
+		log.Printf("%s exited with error: %v", p.Backend.Name(), err)
+		closeSession(session)
 		return err
 	}
 
+	waiting := make(chan struct{})
+	p.mu.Lock()
+	if isClosed(p.stopped) {
+		// Stop was called while a reconnect was starting the player
+		p.mu.Unlock()
+		kill(cmd)
+		cmd.Wait()
+		closeSession(session)
+		return errStopped
+	}
 	p.Process = cmd
+	p.waiting = waiting
+	p.session = session
+	p.variant = variant
+	p.mu.Unlock()
+
+	go p.wait(cmd, session, stderr, waiting)
+	go p.watchStalls(cmd, waiting)
 	return nil
 }
 
-func (p *Player) Stop() {
-	if p.Process != nil {
-		pgid, err := syscall.Getpgid(p.Process.Process.Pid)
-		if err == nil {
-			syscall.Kill(-pgid, syscall.SIGKILL)
-		} else {
-			p.Process.Process.Kill()
-		}
-		p.Process.Wait()
+// wait reaps the player process and, when it ended on its own, tries to reconnect
+// before reporting it on Exits.
+func (p *Player) wait(cmd *exec.Cmd, session *hlsproxy.Session, stderr *tailBuffer, waiting chan struct{}) {
+	err := cmd.Wait()
+	closeSession(session)
+	close(waiting)
+
+	p.mu.Lock()
+	if p.session == session {
+		p.session = nil
+	}
+	stopped := p.Process != cmd
+	background := p.variant.IsAudioOnly
+	if !stopped {
 		p.Process = nil
+		p.waiting = nil
+		p.reconnecting = p.broadcaster != nil
+	}
+	p.mu.Unlock()
+
+	if stopped {
+		return
+	}
+
+	p.Backend.Close()
+	exit := Exit{Err: err, StderrTail: stderr.String(), Background: background}
+	log.Printf("%s exited: %s", p.Backend.Name(), exit.Reason())
+
+	if p.reconnect(&exit) {
+		return
+	}
+
+	select {
+	case p.Exits <- exit:
+	default:
+	}
+}
+
+// reconnect restarts the playback with a freshly resolved url while the channel is
+// still live, waiting exponentially longer between attempts. The exit is updated
+// with the reason of the last failure when it gives up.
+func (p *Player) reconnect(exit *Exit) bool {
+	p.mu.Lock()
+	broadcaster, preferences, stopped := p.broadcaster, p.preferences, p.stopped
+	p.mu.Unlock()
+
+	if broadcaster == nil {
+		return false
+	}
+	defer p.setReconnecting(false)
+
+	streamProvider, configured := p.Cfg.Provider(broadcaster.Provider)
+	if !configured {
+		return false
+	}
+	maxAttempts := p.Cfg.Player.ReconnectAttempts
+	delay := p.Cfg.Player.ReconnectDelay
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		p.status(fmt.Sprintf("Reconnecting (%d/%d)...", attempt, maxAttempts))
+
+		select {
+		case <-time.After(delay):
+		case <-stopped:
+			return true
+		}
+		delay = min(2*delay, p.Cfg.Player.MaxReconnectDelay)
+
+		channel, err := streamProvider.GetChannelInfo(broadcaster.Login)
+		if err != nil {
+			log.Printf("An error occurred while checking if %s is still live: %v", broadcaster.Key(), err)
+			exit.Err = err
+			continue
+		}
+		if !channel.IsLive {
+			exit.Err = nil
+			return false
+		}
+
+		// The cached url is signed and might have expired, always resolve a fresh one
+		p.Streams.Invalidate(broadcaster.Key())
+
+		selection, err := p.GetStreamingUrl(broadcaster, preferences)
+		if err != nil {
+			log.Printf("An error occurred while resolving stream for %s: %v", broadcaster.Key(), err)
+			exit.Err = err
+			continue
+		}
+
+		err = p.playUrl(selection.Variant)
+		if errors.Is(err, errStopped) {
+			return true
+		}
+		if err != nil {
+			exit.Err = err
+			continue
+		}
+
+		p.status(selection.Description())
+		return true
+	}
+
+	return false
+}
+
+// startSession proxies HLS media playlists through a local session, other urls like
+// direct IPTV streams or recordings are played as they are. Starting may wait for an
+// ad break to end, Stop cancels it and errStopped is returned.
+func (p *Player) startSession(streamUrl string) (*hlsproxy.Session, error) {
+	if !p.Cfg.HlsProxy.Enabled || !strings.HasPrefix(streamUrl, "http") {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	stopped := p.stopped
+	p.starting = true
+	p.mu.Unlock()
+
+	session := hlsproxy.NewSession(p.Cfg.HlsProxy, streamUrl, p.alternateUrls(streamUrl))
+	session.OnAdBreak = func() {
+		p.status(hlsproxy.AdBreakStatus)
+	}
+
+	// Closing the session interrupts its start
+	started := make(chan struct{})
+	go func() {
+		select {
+		case <-stopped:
+			session.Close()
+		case <-started:
+		}
+	}()
+	err := session.Start()
+	close(started)
+
+	p.mu.Lock()
+	// A newer playback might be starting already
+	if p.stopped == stopped {
+		p.starting = false
+	}
+	p.mu.Unlock()
+
+	if isClosed(stopped) {
+		session.Close()
+		return nil, errStopped
+	}
+	if err != nil {
+		log.Printf("Playing %s without proxy: %v", streamUrl, err)
+		session.Close()
+		return nil, nil
+	}
+	return session, nil
+}
+
+// alternateUrls returns the urls of the other variants of the stream being played.
+func (p *Player) alternateUrls(streamUrl string) []string {
+	p.mu.Lock()
+	broadcaster := p.broadcaster
+	p.mu.Unlock()
+
+	urls := make([]string, 0)
+	if broadcaster == nil {
+		return urls
+	}
+
+	playlist, err := p.GetStreamPlaylist(broadcaster)
+	if err != nil {
+		return urls
+	}
+
+	for _, variant := range playlist.Variants {
+		if variant.Url == streamUrl {
+			for _, alternate := range hls.AlternateVariants(playlist.Variants, variant) {
+				urls = append(urls, alternate.Url)
+			}
+			break
+		}
+	}
+	return urls
+}
+
+// Stats returns the download stats of the proxied stream, nil when the stream is not proxied.
+func (p *Player) Stats() *hlsproxy.Stats {
+	p.mu.Lock()
+	session := p.session
+	p.mu.Unlock()
+
+	if session == nil {
+		return nil
+	}
+	stats := session.Stats()
+	return &stats
+}
+
+// watchStalls kills the player when it has been buffering for too long, which
+// makes wait reconnect it. Only backends reporting their state can be watched.
+func (p *Player) watchStalls(cmd *exec.Cmd, waiting chan struct{}) {
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+
+	var bufferingSince time.Time
+	for {
+		select {
+		case <-waiting:
+			return
+		case <-ticker.C:
+		}
+
+		state, err := p.Backend.State()
+		if errors.Is(err, ErrUnsupported) {
+			return
+		}
+		// Held back ads starve the player on purpose
+		if stats := p.Stats(); stats != nil && stats.SkippingAd {
+			bufferingSince = time.Time{}
+			continue
+		}
+		if err != nil || !state.Buffering {
+			bufferingSince = time.Time{}
+			continue
+		}
+
+		if bufferingSince.IsZero() {
+			bufferingSince = time.Now()
+		} else if time.Since(bufferingSince) >= p.Cfg.Player.StallTimeout {
+			log.Printf("%s stalled for %v, restarting", p.Backend.Name(), p.Cfg.Player.StallTimeout)
+			kill(cmd)
+			return
+		}
 	}
 }
 
+func (p *Player) Stop() {
+	p.mu.Lock()
+	cmd, waiting := p.Process, p.waiting
+	p.Process = nil
+	p.waiting = nil
+	p.reconnecting = false
+	p.starting = false
+	if p.stopped != nil && !isClosed(p.stopped) {
+		close(p.stopped)
+	}
+	stopViews := p.detachViews()
+	p.mu.Unlock()
+
+	stopViews()
+	if cmd == nil {
+		return
+	}
+
+	kill(cmd)
+	<-waiting
+	p.Backend.Close()
+}
+
+// IsPlaying reports whether a player is running or being reconnected.
 func (p *Player) IsPlaying() bool {
-	return p.Process != nil
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.Process != nil || p.reconnecting || p.starting || len(p.views) > 0
+}
+
+// IsInForeground reports whether a player window covers the app, the app only takes
+// input to stop it then. Audio only playback runs in the background.
+func (p *Player) IsInForeground() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.views) > 0 || p.starting || ((p.Process != nil || p.reconnecting) && !p.variant.IsAudioOnly)
+}
+
+// String returns the now playing line, e.g. "Now playing: Lofi Girl (audio_only) 12:34".
+func (n *NowPlaying) String() string {
+	elapsed := int(time.Since(n.StartedAt).Seconds())
+	return fmt.Sprintf("Now playing: %s (%s) %d:%02d", n.Name(), n.Variant.Quality(), elapsed/60, elapsed%60)
+}
+
+func (n *NowPlaying) Name() string {
+	if n.Broadcaster != nil {
+		return n.Broadcaster.DisplayName
+	}
+	return n.Variant.Name
+}
+
+// NowPlaying returns the stream playing in the background, nil when there is none.
+func (p *Player) NowPlaying() *NowPlaying {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if (p.Process == nil && !p.reconnecting) || !p.variant.IsAudioOnly {
+		return nil
+	}
+	return &NowPlaying{Broadcaster: p.broadcaster, Variant: p.variant, StartedAt: p.startedAt}
+}
+
+func (p *Player) TogglePause() error {
+	if !p.IsPlaying() {
+		return nil
+	}
+	if err := p.controlledBackend().TogglePause(); err != nil {
+		return err
+	}
+
+	// Keep track of the time spent paused, for the position in videos
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pausedAt.IsZero() {
+		p.pausedAt = time.Now()
+	} else {
+		p.pausedFor += time.Since(p.pausedAt)
+		p.pausedAt = time.Time{}
+	}
+	return nil
+}
+
+func (p *Player) ToggleMute() error {
+	if !p.IsPlaying() {
+		return nil
+	}
+	return p.controlledBackend().ToggleMute()
+}
+
+func (p *Player) SetVolume(volume int) error {
+	if !p.IsPlaying() {
+		return nil
+	}
+	return p.controlledBackend().SetVolume(volume)
+}
+
+// ChangeVolume moves the volume by delta percent, keeping it between 0 and 100, and
+// returns the new volume.
+func (p *Player) ChangeVolume(delta int) (int, error) {
+	state, err := p.State()
+	if err != nil || state == nil {
+		return 0, err
+	}
+
+	volume := min(max(state.Volume+delta, 0), 100)
+	return volume, p.SetVolume(volume)
+}
+
+// ShowText shows a message on top of the video, when the backend can.
+func (p *Player) ShowText(text string) error {
+	if !p.IsInForeground() {
+		return nil
+	}
+	return p.controlledBackend().ShowText(text)
+}
+
+// Seek moves a video by the given number of seconds, restarting it at the new position.
+// Live streams can only be moved within what the backend buffered.
+func (p *Player) Seek(seconds float64) error {
+	if !p.IsPlaying() {
+		return nil
+	}
+
+	p.mu.Lock()
+	video, variant := p.video, p.variant
+	p.mu.Unlock()
+
+	if video == nil {
+		return p.controlledBackend().Seek(seconds)
+	}
+
+	position, duration, _ := p.VideoPosition()
+	target := position + time.Duration(seconds*float64(time.Second))
+	if duration > 0 {
+		target = min(target, duration-videoEndMargin)
+	}
+	return p.PlayVideo(video, variant, max(target, 0))
+}
+
+// VideoPosition returns how far into the video the playback is and the length of the
+// video, ok being false when no video plays.
+func (p *Player) VideoPosition() (position time.Duration, duration time.Duration, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.video == nil || (p.Process == nil && !p.reconnecting) {
+		return 0, 0, false
+	}
+
+	paused := p.pausedFor
+	if !p.pausedAt.IsZero() {
+		paused += time.Since(p.pausedAt)
+	}
+	position = p.startOffset + time.Since(p.startedAt) - paused
+	if p.video.Duration > 0 {
+		position = min(position, p.video.Duration)
+	}
+	return position, p.video.Duration, true
+}
+
+func (p *Player) State() (*PlaybackState, error) {
+	if !p.IsPlaying() {
+		return nil, nil
+	}
+	return p.controlledBackend().State()
+}
+
+// controlledBackend is the backend the playback controls go to, the one playing the
+// audio of a multi-view.
+func (p *Player) controlledBackend() PlayerBackend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.views) > 0 {
+		return p.views[p.audioView].backend
+	}
+	return p.Backend
+}
+
+// VideoFrames returns the frame buffers of the embedded players, for the app to draw.
+func (p *Player) VideoFrames() []*video.FrameBuffer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	frames := make([]*video.FrameBuffer, 0)
+	if len(p.views) == 0 {
+		if embedded, ok := p.Backend.(*EmbeddedBackend); ok {
+			frames = append(frames, embedded.Frames)
+		}
+	}
+	for _, v := range p.views {
+		if embedded, ok := v.backend.(*EmbeddedBackend); ok {
+			frames = append(frames, embedded.Frames)
+		}
+	}
+	return frames
+}
+
+func (p *Player) setReconnecting(reconnecting bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reconnecting = reconnecting
+}
+
+// status replaces any status the app did not pick up yet, only the latest one matters.
+func (p *Player) status(text string) {
+	select {
+	case <-p.Statuses:
+	default:
+	}
+	select {
+	case p.Statuses <- text:
+	default:
+	}
+}
+
+// reconnectPreferences puts the quality being played ahead of the configured preferences.
+func reconnectPreferences(variant hls.Variant, preferences []string) []string {
+	quality := variant.Quality()
+	if variant.IsSource {
+		quality = hls.SourceQuality
+	} else if variant.IsAudioOnly {
+		quality = hls.AudioOnlyGroupId
+	}
+	return append([]string{quality}, preferences...)
+}
+
+// kill stops the player process group, so the processes the player spawned stop too.
+func kill(cmd *exec.Cmd) {
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	if err == nil {
+		syscall.Kill(-pgid, syscall.SIGKILL)
+	} else {
+		cmd.Process.Kill()
+	}
+}
+
+func closeSession(session *hlsproxy.Session) {
+	if session != nil {
+		session.Close()
+	}
+}
+
+func isClosed(channel chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	default:
+		return false
+	}
 }
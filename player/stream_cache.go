@@ -0,0 +1,126 @@
+package player
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/hls"
+)
+
+// ResolveFunc fetches the master playlist of a stream.
+type ResolveFunc func() (*hls.MasterPlaylist, error)
+
+type streamCacheEntry struct {
+	playlist   *hls.MasterPlaylist
+	expiresAt  time.Time
+	refreshing bool
+}
+
+// pendingResolve is a resolve in flight, shared by the callers missing the same key.
+type pendingResolve struct {
+	done     chan struct{}
+	playlist *hls.MasterPlaylist
+	err      error
+}
+
+// StreamCache keeps the master playlists of streams per broadcaster until their signed
+// token expires. Entries close to expiring are refreshed in the background while the
+// current url is still handed out, failed refreshes drop the entry.
+type StreamCache struct {
+	// Ttl is used for streams whose url carries no expiry
+	Ttl          time.Duration
+	RefreshAhead time.Duration
+	mu           sync.Mutex
+	entries      map[string]*streamCacheEntry
+	pending      map[string]*pendingResolve
+}
+
+func NewStreamCache(ttl time.Duration, refreshAhead time.Duration) *StreamCache {
+	return &StreamCache{Ttl: ttl, RefreshAhead: refreshAhead, entries: make(map[string]*streamCacheEntry), pending: make(map[string]*pendingResolve)}
+}
+
+// Get returns the cached playlist for the key, resolving it when missing or expired.
+// Callers missing the same key at once wait for a single resolve.
+func (c *StreamCache) Get(key string, resolve ResolveFunc) (*hls.MasterPlaylist, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.removeExpired(now)
+	entry, exists := c.entries[key]
+	if exists {
+		if !entry.refreshing && now.After(entry.expiresAt.Add(-c.RefreshAhead)) {
+			entry.refreshing = true
+			go c.refresh(key, entry, resolve)
+		}
+		c.mu.Unlock()
+		return entry.playlist, nil
+	}
+	if call, exists := c.pending[key]; exists {
+		c.mu.Unlock()
+		<-call.done
+		return call.playlist, call.err
+	}
+	call := &pendingResolve{done: make(chan struct{})}
+	c.pending[key] = call
+	c.mu.Unlock()
+
+	call.playlist, call.err = resolve()
+
+	c.mu.Lock()
+	// An invalidation while resolving means the playlist may already be stale
+	if c.pending[key] == call {
+		delete(c.pending, key)
+		if call.err == nil {
+			c.entries[key] = c.newEntry(call.playlist)
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.playlist, call.err
+}
+
+func (c *StreamCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	delete(c.pending, key)
+}
+
+func (c *StreamCache) refresh(key string, entry *streamCacheEntry, resolve ResolveFunc) {
+	playlist, err := resolve()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The entry might have been invalidated or replaced in the meantime
+	if c.entries[key] != entry {
+		return
+	}
+
+	if err != nil {
+		log.Printf("An error occurred while refreshing stream for: %s, %v", key, err)
+		delete(c.entries, key)
+		return
+	}
+
+	c.entries[key] = c.newEntry(playlist)
+}
+
+func (c *StreamCache) newEntry(playlist *hls.MasterPlaylist) *streamCacheEntry {
+	expiresAt := playlist.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(c.Ttl)
+	}
+	return &streamCacheEntry{playlist: playlist, expiresAt: expiresAt}
+}
+
+func (c *StreamCache) removeExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
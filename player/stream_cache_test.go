@@ -0,0 +1,216 @@
+package player
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/hls"
+)
+
+// testResolver hands out the queued results, counting the resolves
+type testResolver struct {
+	mu      sync.Mutex
+	calls   int
+	results []testResult
+}
+
+type testResult struct {
+	playlist *hls.MasterPlaylist
+	err      error
+}
+
+func (r *testResolver) resolve() (*hls.MasterPlaylist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := r.results[r.calls]
+	r.calls++
+	return result.playlist, result.err
+}
+
+func (r *testResolver) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// waitForCalls polls the resolver until it was called count times
+func (r *testResolver) waitForCalls(t *testing.T, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.callCount() < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("resolver called %d times, want %d", r.callCount(), count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func playlistExpiringIn(d time.Duration) *hls.MasterPlaylist {
+	return &hls.MasterPlaylist{ExpiresAt: time.Now().Add(d)}
+}
+
+func TestStreamCacheConcurrentMiss(t *testing.T) {
+	cache := NewStreamCache(time.Minute, 0)
+	playlist := &hls.MasterPlaylist{}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	resolve := func() (*hls.MasterPlaylist, error) {
+		calls.Add(1)
+		<-release
+		return playlist, nil
+	}
+
+	const callers = 10
+	var started, done sync.WaitGroup
+	results := make([]*hls.MasterPlaylist, callers)
+	for i := range callers {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			results[i], _ = cache.Get("alice", resolve)
+		}()
+	}
+	started.Wait()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("resolved %d times, want once", calls.Load())
+	}
+	for i, result := range results {
+		if result != playlist {
+			t.Errorf("caller %d got %p, want the resolved playlist", i, result)
+		}
+	}
+}
+
+func TestStreamCacheFailedResolve(t *testing.T) {
+	cache := NewStreamCache(time.Minute, 0)
+	resolver := &testResolver{results: []testResult{{err: errors.New("offline")}, {playlist: &hls.MasterPlaylist{}}}}
+
+	if _, err := cache.Get("alice", resolver.resolve); err == nil {
+		t.Fatal("Get() error = nil, want the resolve error")
+	}
+	if playlist, err := cache.Get("alice", resolver.resolve); err != nil || playlist != resolver.results[1].playlist {
+		t.Errorf("Get() = %p, %v, want the failed resolve to not be cached", playlist, err)
+	}
+}
+
+func TestStreamCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		playlist *hls.MasterPlaylist
+		// cached tells whether a second Get reuses the first playlist
+		cached bool
+	}{
+		{"playlist expiry in the future", 0, playlistExpiringIn(time.Hour), true},
+		{"playlist expiry in the past", time.Hour, playlistExpiringIn(-time.Second), false},
+		{"ttl without a playlist expiry", time.Hour, &hls.MasterPlaylist{}, true},
+		{"expired ttl without a playlist expiry", -time.Second, &hls.MasterPlaylist{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewStreamCache(tt.ttl, 0)
+			resolver := &testResolver{results: []testResult{{playlist: tt.playlist}, {playlist: &hls.MasterPlaylist{}}}}
+
+			cache.Get("alice", resolver.resolve)
+			playlist, _ := cache.Get("alice", resolver.resolve)
+
+			if cached := playlist == tt.playlist; cached != tt.cached {
+				t.Errorf("second Get() returned the first playlist = %v, want %v", cached, tt.cached)
+			}
+			want := 2
+			if tt.cached {
+				want = 1
+			}
+			if resolver.callCount() != want {
+				t.Errorf("resolved %d times, want %d", resolver.callCount(), want)
+			}
+		})
+	}
+}
+
+func TestStreamCacheRefreshAhead(t *testing.T) {
+	cache := NewStreamCache(time.Hour, 10*time.Minute)
+	first := playlistExpiringIn(5 * time.Minute)
+	refreshed := playlistExpiringIn(time.Hour)
+	resolver := &testResolver{results: []testResult{{playlist: first}, {playlist: refreshed}}}
+
+	cache.Get("alice", resolver.resolve)
+	// Within the refresh window the current playlist is still handed out
+	if playlist, _ := cache.Get("alice", resolver.resolve); playlist != first {
+		t.Errorf("Get() = %p, want the cached playlist while refreshing", playlist)
+	}
+	resolver.waitForCalls(t, 2)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		playlist, _ := cache.Get("alice", resolver.resolve)
+		if playlist == refreshed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Get() never returned the refreshed playlist")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if resolver.callCount() != 2 {
+		t.Errorf("resolved %d times, want a single refresh", resolver.callCount())
+	}
+}
+
+func TestStreamCacheFailedRefresh(t *testing.T) {
+	cache := NewStreamCache(time.Hour, 10*time.Minute)
+	resolver := &testResolver{results: []testResult{
+		{playlist: playlistExpiringIn(5 * time.Minute)},
+		{err: errors.New("offline")},
+		{playlist: playlistExpiringIn(time.Hour)},
+	}}
+
+	cache.Get("alice", resolver.resolve)
+	cache.Get("alice", resolver.resolve)
+	resolver.waitForCalls(t, 2)
+
+	// The failed refresh drops the entry, so the next Get resolves again
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		playlist, _ := cache.Get("alice", resolver.resolve)
+		if playlist == resolver.results[2].playlist {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Get() kept the playlist whose refresh failed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamCacheInvalidateWhileResolving(t *testing.T) {
+	cache := NewStreamCache(time.Hour, 0)
+	stale := &hls.MasterPlaylist{}
+	fresh := &hls.MasterPlaylist{}
+
+	resolve := func() (*hls.MasterPlaylist, error) {
+		cache.Invalidate("alice")
+		return stale, nil
+	}
+	if playlist, _ := cache.Get("alice", resolve); playlist != stale {
+		t.Errorf("Get() = %p, want the resolved playlist", playlist)
+	}
+
+	resolver := &testResolver{results: []testResult{{playlist: fresh}}}
+	if playlist, _ := cache.Get("alice", resolver.resolve); playlist != fresh {
+		t.Errorf("Get() = %p, want the playlist resolved while invalidated to not be cached", playlist)
+	}
+}
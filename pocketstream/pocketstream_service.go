@@ -2,6 +2,7 @@ package pocketstream
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/fspasovski/pocketstream-app/config"
 	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/twitch"
 )
 
 type PocketstreamService struct {
@@ -29,12 +31,24 @@ func NewPocketstreamService(cfg *config.Config) (service *PocketstreamService) {
 	}
 }
 
+// GetStreams returns the live streams of the given Twitch logins, none when they could
+// not be fetched.
 func (s *PocketstreamService) GetStreams(userLogins []string) []model.Stream {
+	streams, err := s.FetchStreams(userLogins)
+	if err != nil {
+		log.Printf("Error occurred while fetching streams for user logins: %v, %v", userLogins, err)
+		return make([]model.Stream, 0)
+	}
+	return streams
+}
+
+// FetchStreams returns the live streams of the given Twitch logins, telling a failed
+// request apart from none of them being live.
+func (s *PocketstreamService) FetchStreams(userLogins []string) ([]model.Stream, error) {
 	result := make([]model.Stream, 0)
 	targetUrl, err := url.Parse(s.apiUrl + "/streams")
 	if err != nil {
-		log.Printf("Error occurred while parsing streams api url: %v, %v", s.apiUrl, err)
-		return result
+		return nil, fmt.Errorf("parsing streams api url: %s, %w", s.apiUrl, err)
 	}
 
 	queryParams := url.Values{"user_login": userLogins}
@@ -43,33 +57,28 @@ func (s *PocketstreamService) GetStreams(userLogins []string) []model.Stream {
 	targetUrl.RawQuery = queryParams.Encode()
 
 	req, err := http.NewRequest("GET", targetUrl.String(), nil)
-
 	if err != nil {
-		log.Printf("Error occurred while creating streams request for user logins: %v, %v", userLogins, err)
-		return result
+		return nil, err
 	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		log.Printf("Error occurred while fetching streams for user logins: %v, %v", userLogins, err)
-		return result
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading streams response body: %v", err)
-		return result
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("streams api responded with status %d", resp.StatusCode)
 	}
 
-	var streamsResponse StreamsResponse
-	err = json.Unmarshal(body, &streamsResponse)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error unmarshalling json: %v", err)
-		return result
+		return nil, fmt.Errorf("reading streams response body: %w", err)
 	}
 
-	if streamsResponse.Data == nil {
-		return result
+	var streamsResponse StreamsResponse
+	if err := json.Unmarshal(body, &streamsResponse); err != nil {
+		return nil, fmt.Errorf("unmarshalling streams response: %w", err)
 	}
 
 	for i := 0; i < len(streamsResponse.Data); i++ {
@@ -79,10 +88,11 @@ func (s *PocketstreamService) GetStreams(userLogins []string) []model.Stream {
 			PreviewImageURL: streamsResponse.Data[i].PreviewImageURL,
 			ViewersCount:    streamsResponse.Data[i].ViewerCount,
 			Broadcaster: &model.Broadcaster{
-				Id:    streamsResponse.Data[i].Broadcaster.Id,
-				Login: streamsResponse.Data[i].Broadcaster.Login,
+				Provider: twitch.ProviderName,
+				Id:       streamsResponse.Data[i].Broadcaster.Id,
+				Login:    streamsResponse.Data[i].Broadcaster.Login,
 			},
 		})
 	}
-	return result
+	return result, nil
 }
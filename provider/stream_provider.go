@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+// StreamProvider is a streaming backend the screens and the player can browse and
+// play from. Streams and broadcasters it returns carry its Name as their provider.
+type StreamProvider interface {
+	Name() string
+	GetTopStreams(cursor string) ([]model.Stream, error)
+	SearchStreams(query string, cursor string) ([]model.Stream, error)
+	GetStreamPlaylist(login string) (*hls.MasterPlaylist, error)
+	GetChannelInfo(login string) (*model.Channel, error)
+}
+
+// CategoryProvider is implemented by providers that group their streams into
+// categories, e.g. games on Twitch.
+type CategoryProvider interface {
+	GetTopCategories() ([]model.Category, error)
+	GetCategoryStreams(categoryName string) ([]model.Stream, error)
+}
+
+// StreamDetailsProvider is implemented by providers that know more about a live stream
+// than their lists show, e.g. its category, tags and uptime on Twitch.
+type StreamDetailsProvider interface {
+	GetStreamDetails(login string) (*model.StreamDetails, error)
+}
+
+// VideoProvider is implemented by providers that keep the past broadcasts and highlights
+// of their channels.
+type VideoProvider interface {
+	GetVideos(login string) ([]model.Video, error)
+	GetVideoPlaylist(videoId string) (*hls.MasterPlaylist, error)
+}
+
+// ClipProvider is implemented by providers that keep the top clips of their channels and
+// categories. Clips are returned as videos and played from their direct video files.
+type ClipProvider interface {
+	GetBroadcasterClips(login string, period model.ClipPeriod) ([]model.Video, error)
+	GetCategoryClips(categoryName string, period model.ClipPeriod) ([]model.Video, error)
+	GetClipVariants(clipId string) ([]hls.Variant, error)
+}
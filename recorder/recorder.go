@@ -0,0 +1,224 @@
+package recorder
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/config"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+const (
+	fileExtension   = ".ts"
+	timestampFormat = "2006-01-02_15-04-05"
+)
+
+var ErrLowDiskSpace = errors.New("not enough free disk space to record")
+
+// Recording is an ffmpeg process copying a live stream into a file.
+type Recording struct {
+	Broadcaster *model.Broadcaster
+	Path        string
+	StartedAt   time.Time
+	cmd         *exec.Cmd
+	done        chan struct{}
+}
+
+// Recorder saves live streams to the configured directory, any number of streams
+// can be recorded at once but only one per broadcaster.
+type Recorder struct {
+	Config     config.RecorderConfig
+	mu         sync.Mutex
+	recordings map[string]*Recording
+}
+
+func NewRecorder(cfg config.RecorderConfig) *Recorder {
+	return &Recorder{Config: cfg, recordings: make(map[string]*Recording)}
+}
+
+// Start records the stream url of the broadcaster into a new timestamped file.
+func (r *Recorder) Start(broadcaster *model.Broadcaster, streamUrl string) (*Recording, error) {
+	if !r.reserve(broadcaster) {
+		return nil, fmt.Errorf("%s is already being recorded", broadcaster.Login)
+	}
+
+	if err := os.MkdirAll(r.Config.Directory, 0755); err != nil {
+		r.release(broadcaster)
+		return nil, err
+	}
+
+	if !r.hasFreeSpace() {
+		r.release(broadcaster)
+		return nil, ErrLowDiskSpace
+	}
+
+	startedAt := time.Now()
+	path := filepath.Join(r.Config.Directory, fmt.Sprintf("%s_%s%s", fileName(broadcaster.Login), startedAt.Format(timestampFormat), fileExtension))
+
+	cmd := exec.Command("ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", streamUrl,
+		"-c", "copy",
+		"-f", "mpegts",
+		path,
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		r.release(broadcaster)
+		return nil, err
+	}
+
+	recording := &Recording{Broadcaster: broadcaster, Path: path, StartedAt: startedAt, cmd: cmd, done: make(chan struct{})}
+
+	r.mu.Lock()
+	r.recordings[broadcaster.Key()] = recording
+	r.mu.Unlock()
+
+	go r.wait(recording)
+	go r.guardDiskSpace(recording)
+
+	log.Printf("Recording %s to %s", broadcaster.Key(), path)
+	return recording, nil
+}
+
+// Stop asks ffmpeg to finish the file of the broadcaster recording and waits for it.
+func (r *Recorder) Stop(broadcaster *model.Broadcaster) {
+	r.mu.Lock()
+	recording := r.recordings[broadcaster.Key()]
+	r.mu.Unlock()
+
+	if recording != nil {
+		recording.stop()
+	}
+}
+
+func (r *Recorder) StopAll() {
+	r.mu.Lock()
+	recordings := make([]*Recording, 0, len(r.recordings))
+	for _, recording := range r.recordings {
+		if recording != nil {
+			recordings = append(recordings, recording)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, recording := range recordings {
+		recording.stop()
+	}
+}
+
+func (r *Recorder) IsRecording(broadcaster *model.Broadcaster) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.recordings[broadcaster.Key()]
+	return exists
+}
+
+// StopFile stops the recording writing to the file at path, if any.
+func (r *Recorder) StopFile(path string) {
+	if recording := r.activeRecording(path); recording != nil {
+		recording.stop()
+	}
+}
+
+// reserve claims the broadcaster for a recording about to start, with no recording yet
+// until ffmpeg runs, and reports whether it was free.
+func (r *Recorder) reserve(broadcaster *model.Broadcaster) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.recordings[broadcaster.Key()]; exists {
+		return false
+	}
+	r.recordings[broadcaster.Key()] = nil
+	return true
+}
+
+// release frees the broadcaster reserved for a recording that could not start.
+func (r *Recorder) release(broadcaster *model.Broadcaster) {
+	r.mu.Lock()
+	delete(r.recordings, broadcaster.Key())
+	r.mu.Unlock()
+}
+
+func (r *Recorder) wait(recording *Recording) {
+	if err := recording.cmd.Wait(); err != nil {
+		log.Printf("Recording of %s ended with error: %v", recording.Broadcaster.Key(), err)
+	}
+	close(recording.done)
+
+	r.mu.Lock()
+	if r.recordings[recording.Broadcaster.Key()] == recording {
+		delete(r.recordings, recording.Broadcaster.Key())
+	}
+	r.mu.Unlock()
+}
+
+// guardDiskSpace stops the recording before it fills up the disk.
+func (r *Recorder) guardDiskSpace(recording *Recording) {
+	ticker := time.NewTicker(r.Config.DiskCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-recording.done:
+			return
+		case <-ticker.C:
+		}
+
+		if !r.hasFreeSpace() {
+			log.Printf("Stopping recording of %s, free disk space is below %d MB", recording.Broadcaster.Key(), r.Config.MinFreeBytes/(1024*1024))
+			recording.stop()
+			return
+		}
+	}
+}
+
+func (r *Recorder) hasFreeSpace() bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(r.Config.Directory, &stat); err != nil {
+		log.Printf("An error occurred while checking free disk space of %s: %v", r.Config.Directory, err)
+		return false
+	}
+	return stat.Bavail*uint64(stat.Bsize) >= r.Config.MinFreeBytes
+}
+
+// stop interrupts ffmpeg, which makes it flush and close the file, and kills it when
+// it does not exit in time.
+func (recording *Recording) stop() {
+	recording.cmd.Process.Signal(os.Interrupt)
+
+	select {
+	case <-recording.done:
+	case <-time.After(5 * time.Second):
+		syscall.Kill(-recording.cmd.Process.Pid, syscall.SIGKILL)
+		<-recording.done
+	}
+}
+
+// fileName keeps the characters of the login that are safe in a file name, logins of
+// IPTV channels may be urls.
+func fileName(login string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, login)
+}
+
+// Name returns the file name without its extension, e.g. "xqc_2024-05-01_20-15-00".
+func (recording *Recording) Name() string {
+	return strings.TrimSuffix(filepath.Base(recording.Path), fileExtension)
+}
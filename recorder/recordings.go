@@ -0,0 +1,99 @@
+package recorder
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordingFile is a recording saved in the recordings directory.
+type RecordingFile struct {
+	Name      string
+	Path      string
+	Size      int64
+	Duration  time.Duration
+	CreatedAt time.Time
+	// Recording is set while the file is still being written
+	Recording bool
+}
+
+// ListRecordings returns the recorded files, newest first. The duration of finished
+// recordings is read with ffprobe, the one of active recordings is the time elapsed.
+func (r *Recorder) ListRecordings() ([]RecordingFile, error) {
+	entries, err := os.ReadDir(r.Config.Directory)
+	if os.IsNotExist(err) {
+		return make([]RecordingFile, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]RecordingFile, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExtension {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		file := RecordingFile{
+			Name:      strings.TrimSuffix(entry.Name(), fileExtension),
+			Path:      filepath.Join(r.Config.Directory, entry.Name()),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		}
+
+		if recording := r.activeRecording(file.Path); recording != nil {
+			file.Recording = true
+			file.CreatedAt = recording.StartedAt
+			file.Duration = time.Since(recording.StartedAt)
+		} else {
+			file.Duration = probeDuration(file.Path)
+		}
+
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].CreatedAt.After(files[j].CreatedAt)
+	})
+
+	return files, nil
+}
+
+func (r *Recorder) activeRecording(path string) *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, recording := range r.recordings {
+		if recording != nil && recording.Path == path {
+			return recording
+		}
+	}
+	return nil
+}
+
+func probeDuration(path string) time.Duration {
+	output, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
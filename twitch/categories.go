@@ -0,0 +1,93 @@
+package twitch
+
+import (
+	"fmt"
+
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+func (s *TwitchService) GetTopCategories() ([]model.Category, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "TopCategories",
+		Query:         "query TopCategories($limit: Int!, $boxArtWidth: Int!, $boxArtHeight: Int!) { games(first: $limit) { edges { node { id name displayName viewersCount boxArtURL(width: $boxArtWidth, height: $boxArtHeight) } } } }",
+		Variables: &GqlRequestVariables{
+			Limit:        s.Config.TopCategoriesLimit,
+			BoxArtWidth:  s.Config.BoxArtWidth,
+			BoxArtHeight: s.Config.BoxArtHeight,
+		},
+	}
+
+	var parsedResponse TopCategoriesGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.Games == nil {
+		return nil, fmt.Errorf("no categories in gql response")
+	}
+
+	categories := make([]model.Category, 0, len(parsedResponse.Data.Games.Edges))
+	for _, edge := range parsedResponse.Data.Games.Edges {
+		if edge.Node == nil {
+			continue
+		}
+
+		categories = append(categories, model.Category{
+			Id:           edge.Node.Id,
+			Name:         edge.Node.Name,
+			DisplayName:  edge.Node.DisplayName,
+			ViewersCount: edge.Node.ViewersCount,
+			BoxArtURL:    edge.Node.BoxArtURL,
+		})
+	}
+
+	return categories, nil
+}
+
+// GetCategoryStreams returns the live streams of a category without image data,
+// the images are expected to be loaded through the ImageDataService.
+func (s *TwitchService) GetCategoryStreams(categoryName string) ([]model.Stream, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "CategoryStreams",
+		Query:         "query CategoryStreams($name: String!, $limit: Int!, $previewWidth: Int!, $previewHeight: Int!, $imageWidth: Int!) { game(name: $name) { streams(first: $limit) { edges { node { id title viewersCount previewImageUrl: previewImageURL(width: $previewWidth, height: $previewHeight) broadcaster { id login displayName profileImageURL(width: $imageWidth) } } } } } }",
+		Variables: &GqlRequestVariables{
+			Name:          categoryName,
+			Limit:         s.Config.CategoryStreamsLimit,
+			ImageWidth:    50,
+			PreviewWidth:  s.Config.PreviewImageWidth,
+			PreviewHeight: s.Config.PreviewImageHeight,
+		},
+	}
+
+	var parsedResponse CategoryStreamsGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.Game == nil || parsedResponse.Data.Game.Streams == nil {
+		return nil, fmt.Errorf("no streams in gql response for category: %s", categoryName)
+	}
+
+	streams := make([]model.Stream, 0, len(parsedResponse.Data.Game.Streams.Edges))
+	for _, edge := range parsedResponse.Data.Game.Streams.Edges {
+		if edge.Node == nil || edge.Node.Broadcaster == nil {
+			continue
+		}
+
+		streams = append(streams, model.Stream{
+			Id:              edge.Node.Id,
+			Title:           edge.Node.Title,
+			ViewersCount:    edge.Node.ViewersCount,
+			PreviewImageURL: edge.Node.PreviewImageURL,
+			Broadcaster: &model.Broadcaster{
+				Provider:        ProviderName,
+				Id:              edge.Node.Broadcaster.Id,
+				Login:           edge.Node.Broadcaster.Login,
+				DisplayName:     edge.Node.Broadcaster.DisplayName,
+				ProfileImageURL: edge.Node.Broadcaster.ProfileImageURL,
+			},
+		})
+	}
+
+	return streams, nil
+}
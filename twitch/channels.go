@@ -0,0 +1,53 @@
+package twitch
+
+import (
+	"fmt"
+
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+func (s *TwitchService) GetChannelInfo(login string) (*model.Channel, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "ChannelInfo",
+		Query:         "query ChannelInfo($login: String!, $imageWidth: Int!, $previewWidth: Int!, $previewHeight: Int!) { user(login: $login) { id login displayName profileImageURL(width: $imageWidth) stream { id title type viewersCount previewImageUrl: previewImageURL(width: $previewWidth, height: $previewHeight) } } }",
+		Variables: &GqlRequestVariables{
+			Login:         login,
+			ImageWidth:    50,
+			PreviewWidth:  s.Config.PreviewImageWidth,
+			PreviewHeight: s.Config.PreviewImageHeight,
+		},
+	}
+
+	var parsedResponse ChannelInfoGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.User == nil {
+		return nil, fmt.Errorf("channel not found: %s", login)
+	}
+
+	user := parsedResponse.Data.User
+	channel := &model.Channel{
+		Broadcaster: &model.Broadcaster{
+			Provider:        ProviderName,
+			Id:              user.Id,
+			Login:           user.Login,
+			DisplayName:     user.DisplayName,
+			ProfileImageURL: user.ProfileImageURL,
+		},
+		IsLive: user.Stream != nil && user.Stream.Type == "live",
+	}
+
+	if user.Stream != nil {
+		channel.Stream = &model.Stream{
+			Id:              user.Stream.Id,
+			Title:           user.Stream.Title,
+			ViewersCount:    user.Stream.ViewersCount,
+			PreviewImageURL: user.Stream.PreviewImageURL,
+			Broadcaster:     channel.Broadcaster,
+		}
+	}
+
+	return channel, nil
+}
@@ -0,0 +1,157 @@
+package twitch
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+const clipFields = "id slug title viewCount durationSeconds createdAt thumbnailURL(width: $previewWidth, height: $previewHeight) broadcaster { id login displayName profileImageURL(width: $imageWidth) }"
+
+// GetBroadcasterClips returns the most viewed clips of the channel over the period
+// without image data, the images are expected to be loaded through the ImageDataService.
+func (s *TwitchService) GetBroadcasterClips(login string, period model.ClipPeriod) ([]model.Video, error) {
+	query := "query BroadcasterClips($login: String!, $limit: Int!, $period: ClipsPeriod!, $imageWidth: Int!, $previewWidth: Int!, $previewHeight: Int!) { user(login: $login) { clips(first: $limit, criteria: { period: $period, sort: VIEWS_DESC }) { edges { node { " + clipFields + " } } } } }"
+
+	parsedResponse, err := s.getClips("BroadcasterClips", query, &GqlRequestVariables{Login: login}, period)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.User == nil {
+		return nil, fmt.Errorf("channel not found: %s", login)
+	}
+	return getClips(parsedResponse.Data.User), nil
+}
+
+// GetCategoryClips returns the most viewed clips of the category over the period without
+// image data.
+func (s *TwitchService) GetCategoryClips(categoryName string, period model.ClipPeriod) ([]model.Video, error) {
+	query := "query CategoryClips($name: String!, $limit: Int!, $period: ClipsPeriod!, $imageWidth: Int!, $previewWidth: Int!, $previewHeight: Int!) { game(name: $name) { clips(first: $limit, criteria: { period: $period, sort: VIEWS_DESC }) { edges { node { " + clipFields + " } } } } }"
+
+	parsedResponse, err := s.getClips("CategoryClips", query, &GqlRequestVariables{Name: categoryName}, period)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.Game == nil {
+		return nil, fmt.Errorf("category not found: %s", categoryName)
+	}
+	return getClips(parsedResponse.Data.Game), nil
+}
+
+// GetClipVariants returns the video files of the clip from the highest quality down,
+// signed with the clip playback access token so they can be played directly.
+func (s *TwitchService) GetClipVariants(clipId string) ([]hls.Variant, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "ClipPlayback",
+		Query:         "query ClipPlayback($slug: ID!, $playerType: String!) { clip(slug: $slug) { playbackAccessToken(params: { platform: \"web\", playerBackend: \"mediaplayer\", playerType: $playerType }) { value signature } videoQualities { quality frameRate sourceURL } } }",
+		Variables: &GqlRequestVariables{
+			Slug:       clipId,
+			PlayerType: "embed",
+		},
+	}
+
+	var parsedResponse ClipPlaybackGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.Clip == nil || parsedResponse.Data.Clip.PlaybackAccessToken == nil {
+		return nil, fmt.Errorf("no playback access token for clip: %s", clipId)
+	}
+
+	clip := parsedResponse.Data.Clip
+	variants := make([]hls.Variant, 0, len(clip.VideoQualities))
+	for _, quality := range clip.VideoQualities {
+		if quality == nil || quality.SourceURL == "" {
+			continue
+		}
+
+		height, _ := strconv.Atoi(quality.Quality)
+		name := quality.Quality + "p"
+		if quality.FrameRate > 30 {
+			name += strconv.Itoa(int(quality.FrameRate + 0.5))
+		}
+
+		variants = append(variants, hls.Variant{
+			Name:      name,
+			Url:       fmt.Sprintf("%s?sig=%s&token=%s", quality.SourceURL, clip.PlaybackAccessToken.Signature, url.QueryEscape(clip.PlaybackAccessToken.Value)),
+			Height:    height,
+			FrameRate: quality.FrameRate,
+			IsSource:  len(variants) == 0,
+		})
+	}
+
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("no video files for clip: %s", clipId)
+	}
+	return variants, nil
+}
+
+func (s *TwitchService) getClips(operationName string, query string, variables *GqlRequestVariables, period model.ClipPeriod) (*ClipsGqlResponse, error) {
+	variables.Limit = s.Config.ClipsLimit
+	variables.Period = clipPeriodName(period)
+	variables.ImageWidth = 50
+	variables.PreviewWidth = s.Config.PreviewImageWidth
+	variables.PreviewHeight = s.Config.PreviewImageHeight
+
+	gqlRequest := &GqlRequest{
+		OperationName: operationName,
+		Query:         query,
+		Variables:     variables,
+	}
+
+	var parsedResponse ClipsGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+	return &parsedResponse, nil
+}
+
+func getClips(owner *ClipsOwnerGqlResponse) []model.Video {
+	clips := make([]model.Video, 0)
+	if owner.Clips == nil {
+		return clips
+	}
+
+	for _, edge := range owner.Clips.Edges {
+		if edge.Node == nil || edge.Node.Broadcaster == nil {
+			continue
+		}
+
+		clips = append(clips, model.Video{
+			Id:              edge.Node.Slug,
+			Type:            "clip",
+			Title:           edge.Node.Title,
+			Duration:        time.Duration(edge.Node.DurationSeconds) * time.Second,
+			PublishedAt:     edge.Node.CreatedAt,
+			ViewsCount:      edge.Node.ViewCount,
+			PreviewImageURL: edge.Node.ThumbnailURL,
+			Broadcaster: &model.Broadcaster{
+				Provider:        ProviderName,
+				Id:              edge.Node.Broadcaster.Id,
+				Login:           edge.Node.Broadcaster.Login,
+				DisplayName:     edge.Node.Broadcaster.DisplayName,
+				ProfileImageURL: edge.Node.Broadcaster.ProfileImageURL,
+			},
+		})
+	}
+	return clips
+}
+
+func clipPeriodName(period model.ClipPeriod) string {
+	switch period {
+	case model.ClipPeriodDay:
+		return "LAST_DAY"
+	case model.ClipPeriodWeek:
+		return "LAST_WEEK"
+	case model.ClipPeriodMonth:
+		return "LAST_MONTH"
+	}
+	return "ALL_TIME"
+}
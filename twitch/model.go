@@ -1,5 +1,7 @@
 package twitch
 
+import "time"
+
 type TopChannelEdgeImageResultDto struct {
 	Edge              *TopChannelsEdgeGqlResponse
 	Bytes             []byte
@@ -32,6 +34,17 @@ type GqlRequestVariables struct {
 	SortTypeIsRecency bool               `json:"sortTypeIsRecency"`
 	IncludeIsDJ       bool               `json:"includeIsDJ"`
 	Query             string             `json:"query"`
+	Name              string             `json:"name"`
+	BoxArtWidth       int                `json:"boxArtWidth"`
+	BoxArtHeight      int                `json:"boxArtHeight"`
+	PreviewWidth      int                `json:"previewWidth"`
+	PreviewHeight     int                `json:"previewHeight"`
+	Cursor            string             `json:"cursor,omitempty"`
+	IsVod             bool               `json:"isVod"`
+	VodId             string             `json:"vodID"`
+	Period            string             `json:"period,omitempty"`
+	Logins            []string           `json:"logins,omitempty"`
+	Slug              string             `json:"slug,omitempty"`
 }
 
 type GqlRequestExtensions struct {
@@ -46,6 +59,12 @@ type GqlRequestOptions struct {
 	RecommendationsContext *GqlRequestRecommendationsContext `json:"recommendations_context"`
 	RequestId              string                            `json:"requestID"`
 	BroadcasterLanguages   []string                          `json:"broadcasterLanguages"`
+	Targets                []*GqlRequestSearchTarget         `json:"targets,omitempty"`
+}
+
+type GqlRequestSearchTarget struct {
+	Index  string `json:"index"`
+	Cursor string `json:"cursor"`
 }
 
 type GqlRequestRecommendationsContext struct {
@@ -63,6 +82,7 @@ type StreamingUrlGqlResponse struct {
 
 type StreamingUrlGqlResponseData struct {
 	StreamPlaybackAccessToken *StreamPlaybackAccessToken `json:"streamPlaybackAccessToken"`
+	VideoPlaybackAccessToken  *StreamPlaybackAccessToken `json:"videoPlaybackAccessToken"`
 }
 
 type StreamPlaybackAccessToken struct {
@@ -70,6 +90,11 @@ type StreamPlaybackAccessToken struct {
 	Signature string `json:"signature"`
 }
 
+// PlaybackAccessTokenValue is the part of the signed token value the app reads
+type PlaybackAccessTokenValue struct {
+	Expires int64 `json:"expires"`
+}
+
 type TopChannelsGqlResponse struct {
 	Data *TopChannelsDataGqlResponse
 }
@@ -83,7 +108,8 @@ type TopChannelsStreamsGqlResponse struct {
 }
 
 type TopChannelsEdgeGqlResponse struct {
-	Node *TopChannelsNodeGqlResponse
+	Cursor string `json:"cursor"`
+	Node   *TopChannelsNodeGqlResponse
 }
 
 type TopChannelsNodeGqlResponse struct {
@@ -114,11 +140,13 @@ type SearchStreamsSearchForGqlResponse struct {
 }
 
 type SearchStreamsChannelsGqlResponse struct {
-	Edges []*SearchStreamsEdgeGqlResponse `json:"edges"`
+	Cursor string                          `json:"cursor"`
+	Edges  []*SearchStreamsEdgeGqlResponse `json:"edges"`
 }
 
 type SearchStreamsEdgeGqlResponse struct {
-	Item *SearchStreamsItemGqlResponse `json:"item"`
+	Cursor string                        `json:"cursor"`
+	Item   *SearchStreamsItemGqlResponse `json:"item"`
 }
 
 type SearchStreamsItemGqlResponse struct {
@@ -141,3 +169,201 @@ type SearchStreamsStreamGqlResponse struct {
 	ViewersCount    int    `json:"viewersCount"`
 	PreviewImageURL string `json:"previewImageUrl"`
 }
+
+type TopCategoriesGqlResponse struct {
+	Data *TopCategoriesDataGqlResponse `json:"data"`
+}
+
+type TopCategoriesDataGqlResponse struct {
+	Games *TopCategoriesGamesGqlResponse `json:"games"`
+}
+
+type TopCategoriesGamesGqlResponse struct {
+	Edges []*TopCategoriesEdgeGqlResponse `json:"edges"`
+}
+
+type TopCategoriesEdgeGqlResponse struct {
+	Node *CategoryGqlResponse `json:"node"`
+}
+
+type CategoryGqlResponse struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	ViewersCount int    `json:"viewersCount"`
+	BoxArtURL    string `json:"boxArtURL"`
+}
+
+type CategoryStreamsGqlResponse struct {
+	Data *CategoryStreamsDataGqlResponse `json:"data"`
+}
+
+type CategoryStreamsDataGqlResponse struct {
+	Game *CategoryStreamsGameGqlResponse `json:"game"`
+}
+
+type CategoryStreamsGameGqlResponse struct {
+	Streams *TopChannelsStreamsGqlResponse `json:"streams"`
+}
+
+type ChannelInfoGqlResponse struct {
+	Data *ChannelInfoDataGqlResponse `json:"data"`
+}
+
+type ChannelInfoDataGqlResponse struct {
+	User *ChannelInfoUserGqlResponse `json:"user"`
+}
+
+type ChannelInfoUserGqlResponse struct {
+	Id              string                          `json:"id"`
+	Login           string                          `json:"login"`
+	DisplayName     string                          `json:"displayName"`
+	ProfileImageURL string                          `json:"profileImageURL"`
+	Stream          *SearchStreamsStreamGqlResponse `json:"stream"`
+}
+
+type StreamDetailsGqlResponse struct {
+	Data *StreamDetailsDataGqlResponse `json:"data"`
+}
+
+type StreamDetailsDataGqlResponse struct {
+	User *StreamDetailsUserGqlResponse `json:"user"`
+}
+
+type StreamDetailsUserGqlResponse struct {
+	Id                string                                     `json:"id"`
+	Login             string                                     `json:"login"`
+	DisplayName       string                                     `json:"displayName"`
+	ProfileImageURL   string                                     `json:"profileImageURL"`
+	Followers         *StreamDetailsFollowersGqlResponse         `json:"followers"`
+	BroadcastSettings *StreamDetailsBroadcastSettingsGqlResponse `json:"broadcastSettings"`
+	Stream            *StreamDetailsStreamGqlResponse            `json:"stream"`
+}
+
+type StreamDetailsFollowersGqlResponse struct {
+	TotalCount int `json:"totalCount"`
+}
+
+type StreamDetailsBroadcastSettingsGqlResponse struct {
+	Language string `json:"language"`
+}
+
+type StreamDetailsStreamGqlResponse struct {
+	Id              string                         `json:"id"`
+	Title           string                         `json:"title"`
+	Type            string                         `json:"type"`
+	ViewersCount    int                            `json:"viewersCount"`
+	PreviewImageURL string                         `json:"previewImageUrl"`
+	CreatedAt       time.Time                      `json:"createdAt"`
+	Game            *CategoryGqlResponse           `json:"game"`
+	FreeformTags    []*StreamDetailsTagGqlResponse `json:"freeformTags"`
+}
+
+type StreamDetailsTagGqlResponse struct {
+	Name string `json:"name"`
+}
+
+type ChannelVideosGqlResponse struct {
+	Data *ChannelVideosDataGqlResponse `json:"data"`
+}
+
+type ChannelVideosDataGqlResponse struct {
+	User *ChannelVideosUserGqlResponse `json:"user"`
+}
+
+type ChannelVideosUserGqlResponse struct {
+	Id              string                          `json:"id"`
+	Login           string                          `json:"login"`
+	DisplayName     string                          `json:"displayName"`
+	ProfileImageURL string                          `json:"profileImageURL"`
+	Videos          *ChannelVideosVideosGqlResponse `json:"videos"`
+}
+
+type ChannelVideosVideosGqlResponse struct {
+	Edges []*ChannelVideosEdgeGqlResponse `json:"edges"`
+}
+
+type ChannelVideosEdgeGqlResponse struct {
+	Node *VideoGqlResponse `json:"node"`
+}
+
+type VideoGqlResponse struct {
+	Id                  string    `json:"id"`
+	Title               string    `json:"title"`
+	LengthSeconds       int       `json:"lengthSeconds"`
+	PublishedAt         time.Time `json:"publishedAt"`
+	BroadcastType       string    `json:"broadcastType"`
+	ViewCount           int       `json:"viewCount"`
+	PreviewThumbnailURL string    `json:"previewThumbnailURL"`
+}
+
+type ClipsGqlResponse struct {
+	Data *ClipsDataGqlResponse `json:"data"`
+}
+
+type ClipsDataGqlResponse struct {
+	User *ClipsOwnerGqlResponse `json:"user"`
+	Game *ClipsOwnerGqlResponse `json:"game"`
+}
+
+// ClipsOwnerGqlResponse is the channel or the game whose clips were asked for
+type ClipsOwnerGqlResponse struct {
+	Clips *ClipsConnectionGqlResponse `json:"clips"`
+}
+
+type ClipsConnectionGqlResponse struct {
+	Edges []*ClipEdgeGqlResponse `json:"edges"`
+}
+
+type ClipEdgeGqlResponse struct {
+	Node *ClipGqlResponse `json:"node"`
+}
+
+type ClipGqlResponse struct {
+	Id              string                  `json:"id"`
+	Slug            string                  `json:"slug"`
+	Title           string                  `json:"title"`
+	ViewCount       int                     `json:"viewCount"`
+	DurationSeconds int                     `json:"durationSeconds"`
+	CreatedAt       time.Time               `json:"createdAt"`
+	ThumbnailURL    string                  `json:"thumbnailURL"`
+	Broadcaster     *BroadcasterGqlResponse `json:"broadcaster"`
+}
+
+type ClipPlaybackGqlResponse struct {
+	Data *ClipPlaybackDataGqlResponse `json:"data"`
+}
+
+type ClipPlaybackDataGqlResponse struct {
+	Clip *ClipPlaybackClipGqlResponse `json:"clip"`
+}
+
+type ClipPlaybackClipGqlResponse struct {
+	PlaybackAccessToken *StreamPlaybackAccessToken     `json:"playbackAccessToken"`
+	VideoQualities      []*ClipVideoQualityGqlResponse `json:"videoQualities"`
+}
+
+type ClipVideoQualityGqlResponse struct {
+	Quality   string  `json:"quality"`
+	FrameRate float64 `json:"frameRate"`
+	SourceURL string  `json:"sourceURL"`
+}
+
+type LastBroadcastsGqlResponse struct {
+	Data *LastBroadcastsDataGqlResponse `json:"data"`
+}
+
+type LastBroadcastsDataGqlResponse struct {
+	Users []*LastBroadcastsUserGqlResponse `json:"users"`
+}
+
+type LastBroadcastsUserGqlResponse struct {
+	Login         string                    `json:"login"`
+	LastBroadcast *LastBroadcastGqlResponse `json:"lastBroadcast"`
+	// Videos holds the latest archive, the past broadcast kept by Twitch
+	Videos *ChannelVideosVideosGqlResponse `json:"videos"`
+}
+
+type LastBroadcastGqlResponse struct {
+	StartedAt *time.Time `json:"startedAt"`
+}
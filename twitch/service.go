@@ -7,29 +7,51 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fspasovski/pocketstream-app/hls"
 	"github.com/fspasovski/pocketstream-app/model"
 )
 
 type TwitchConfig struct {
-	ClientId               string
-	GqlUrl                 string
-	UsherUrl               string
-	StreamResolution       string
-	TopStreamsLimit        int
-	HttpClient             *http.Client
-	BrowsPagePopularSha256 string
-	SearchResultsSha256    string
+	ClientId             string
+	GqlUrl               string
+	UsherUrl             string
+	VodUsherUrl          string
+	TopStreamsLimit      int
+	TopCategoriesLimit   int
+	CategoryStreamsLimit int
+	VideosLimit          int
+	ClipsLimit           int
+	BoxArtWidth          int
+	BoxArtHeight         int
+	PreviewImageWidth    int
+	PreviewImageHeight   int
+	// DetailsPreviewImageWidth and Height size the large preview of the stream details screen
+	DetailsPreviewImageWidth  int
+	DetailsPreviewImageHeight int
+	HttpClient                *http.Client
+	BrowsPagePopularSha256    string
+	SearchResultsSha256       string
 }
 
+const ProviderName = "twitch"
+
 type TwitchService struct {
 	Config TwitchConfig
 }
 
-func (s *TwitchService) GetTopStreams() ([]model.Stream, error) {
-	gqlRequest, err := s.getTopChannelsGqlRequest(s.Config.TopStreamsLimit)
+func (s *TwitchService) Name() string {
+	return ProviderName
+}
+
+// GetTopStreams returns the next page of popular streams after the given cursor,
+// an empty cursor returns the first page.
+func (s *TwitchService) GetTopStreams(cursor string) ([]model.Stream, error) {
+	gqlRequest, err := s.getTopChannelsGqlRequest(s.Config.TopStreamsLimit, cursor)
 
 	if err != nil {
 		fmt.Println("Error creating request:", err)
@@ -90,7 +112,9 @@ func (s *TwitchService) GetTopStreams() ([]model.Stream, error) {
 			ViewersCount:     res.Edge.Node.ViewersCount,
 			PreviewImageURL:  res.Edge.Node.PreviewImageURL,
 			PreviewImageData: res.PreviewImageBytes,
+			Cursor:           res.Edge.Cursor,
 			Broadcaster: &model.Broadcaster{
+				Provider:         ProviderName,
 				Id:               res.Edge.Node.Broadcaster.Id,
 				Login:            res.Edge.Node.Broadcaster.Login,
 				DisplayName:      res.Edge.Node.Broadcaster.DisplayName,
@@ -100,11 +124,21 @@ func (s *TwitchService) GetTopStreams() ([]model.Stream, error) {
 		})
 	}
 
+	// Images are fetched concurrently, restore the response order so the cursor of the last stream ends the page
+	positions := make(map[string]int, len(parsedResponse.Data.Streams.Edges))
+	for i, edge := range parsedResponse.Data.Streams.Edges {
+		positions[edge.Node.Broadcaster.Login] = i
+	}
+	sortByPosition(topStreams, positions)
+
 	return topStreams, nil
 }
 
-func (s *TwitchService) SearchStreams(searchValue string) ([]model.Stream, error) {
-	gqlRequest, err := s.getSearchChannelsGqlRequest(&searchValue)
+// SearchStreams returns the channels matching the search value, starting after the
+// given cursor when it is not empty. Channels that are not live are returned as offline
+// streams with the start of their last broadcast.
+func (s *TwitchService) SearchStreams(searchValue string, cursor string) ([]model.Stream, error) {
+	gqlRequest, err := s.getSearchChannelsGqlRequest(&searchValue, cursor)
 
 	if err != nil {
 		fmt.Println("Error creating request:", err)
@@ -145,11 +179,21 @@ func (s *TwitchService) SearchStreams(searchValue string) ([]model.Stream, error
 	var imagesFetchWaitGroup sync.WaitGroup
 	edgesWithImageData := make(chan SearchChannelsEdgeImageResultDto, len(parsedResponse.Data.SearchFor.Channels.Edges))
 
+	offlineLogins := make([]string, 0)
 	for _, edge := range parsedResponse.Data.SearchFor.Channels.Edges {
-		if edge.Item.Stream != nil && edge.Item.Stream.Type == "live" {
-			imagesFetchWaitGroup.Add(1)
-			go getSearchChannelsImageDataFromUrl(edge, &imagesFetchWaitGroup, edgesWithImageData)
+		if edge.Item == nil {
+			continue
+		}
+		if !isLive(edge.Item) {
+			offlineLogins = append(offlineLogins, edge.Item.Login)
 		}
+		imagesFetchWaitGroup.Add(1)
+		go getSearchChannelsImageDataFromUrl(edge, &imagesFetchWaitGroup, edgesWithImageData)
+	}
+
+	lastLives, err := s.getLastBroadcasts(offlineLogins)
+	if err != nil {
+		fmt.Println("Error fetching last broadcasts:", err)
 	}
 
 	imagesFetchWaitGroup.Wait()
@@ -160,51 +204,136 @@ func (s *TwitchService) SearchStreams(searchValue string) ([]model.Stream, error
 			continue
 		}
 
-		streams = append(streams, model.Stream{
-			Id:               res.Edge.Item.Stream.Id,
-			Title:            res.Edge.Item.BroadcastSettings.Title,
-			ViewersCount:     res.Edge.Item.Stream.ViewersCount,
-			PreviewImageURL:  res.Edge.Item.Stream.PreviewImageURL,
+		streamCursor := res.Edge.Cursor
+		if streamCursor == "" {
+			streamCursor = parsedResponse.Data.SearchFor.Channels.Cursor
+		}
+
+		stream := model.Stream{
 			PreviewImageData: res.PreviewImageBytes,
+			Cursor:           streamCursor,
 			Broadcaster: &model.Broadcaster{
+				Provider:         ProviderName,
 				Id:               res.Edge.Item.Id,
 				Login:            res.Edge.Item.Login,
 				DisplayName:      res.Edge.Item.DisplayName,
 				ProfileImageURL:  res.Edge.Item.ProfileImageURL,
 				ProfileImageData: res.Bytes,
 			},
-		})
+		}
+		if res.Edge.Item.BroadcastSettings != nil {
+			stream.Title = res.Edge.Item.BroadcastSettings.Title
+		}
+		if isLive(res.Edge.Item) {
+			stream.Id = res.Edge.Item.Stream.Id
+			stream.ViewersCount = res.Edge.Item.Stream.ViewersCount
+			stream.PreviewImageURL = res.Edge.Item.Stream.PreviewImageURL
+		} else {
+			stream.Offline = true
+			stream.LastLiveAt = lastLives[res.Edge.Item.Login]
+		}
+
+		streams = append(streams, stream)
+	}
+
+	positions := make(map[string]int, len(parsedResponse.Data.SearchFor.Channels.Edges))
+	for i, edge := range parsedResponse.Data.SearchFor.Channels.Edges {
+		if edge.Item != nil {
+			positions[edge.Item.Login] = i
+		}
 	}
+	sortByPosition(streams, positions)
 
 	return streams, nil
 }
 
+func isLive(item *SearchStreamsItemGqlResponse) bool {
+	return item.Stream != nil && item.Stream.Type == "live"
+}
+
+// getLastBroadcasts returns when the last broadcast of each of the channels ended, the
+// start of its archive plus its length. Channels that never streamed or keep no archive
+// of their last broadcast are left out, its end being unknown.
+func (s *TwitchService) getLastBroadcasts(logins []string) (map[string]time.Time, error) {
+	lastBroadcasts := make(map[string]time.Time, len(logins))
+	if len(logins) == 0 {
+		return lastBroadcasts, nil
+	}
+
+	gqlRequest := &GqlRequest{
+		OperationName: "LastBroadcasts",
+		Query:         "query LastBroadcasts($logins: [String!]) { users(logins: $logins) { login lastBroadcast { startedAt } videos(first: 1, sort: TIME, types: [ARCHIVE]) { edges { node { lengthSeconds publishedAt } } } } }",
+		Variables: &GqlRequestVariables{
+			Logins: logins,
+		},
+	}
+
+	var parsedResponse LastBroadcastsGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return lastBroadcasts, err
+	}
+	if parsedResponse.Data == nil {
+		return lastBroadcasts, nil
+	}
+
+	for _, user := range parsedResponse.Data.Users {
+		if user == nil || user.LastBroadcast == nil || user.LastBroadcast.StartedAt == nil || user.Videos == nil || len(user.Videos.Edges) == 0 || user.Videos.Edges[0].Node == nil {
+			continue
+		}
+
+		// An older archive belongs to an earlier broadcast
+		archive := user.Videos.Edges[0].Node
+		if archive.PublishedAt.Before(user.LastBroadcast.StartedAt.Add(-time.Minute)) {
+			continue
+		}
+		lastBroadcasts[user.Login] = archive.PublishedAt.Add(time.Duration(archive.LengthSeconds) * time.Second)
+	}
+	return lastBroadcasts, nil
+}
+
+func sortByPosition(streams []model.Stream, positions map[string]int) {
+	sort.SliceStable(streams, func(i, j int) bool {
+		return positions[streams[i].Broadcaster.Login] < positions[streams[j].Broadcaster.Login]
+	})
+}
+
+// getSearchChannelsImageDataFromUrl fetches the profile image of the channel and the
+// preview of its stream, offline channels have no preview and are kept without a profile
+// image when it cannot be fetched.
 func getSearchChannelsImageDataFromUrl(edge *SearchStreamsEdgeGqlResponse, wg *sync.WaitGroup, results chan<- SearchChannelsEdgeImageResultDto) {
 	defer wg.Done()
 
-	profileImageResp, err := http.Get(edge.Item.ProfileImageURL)
-	if err != nil {
-		results <- SearchChannelsEdgeImageResultDto{Edge: edge, Err: err}
+	data, err := getSearchChannelImageData(edge.Item.ProfileImageURL)
+	if !isLive(edge.Item) {
+		if err != nil {
+			fmt.Println("Error fetching profile image of", edge.Item.Login, ":", err)
+			data = nil
+		}
+		results <- SearchChannelsEdgeImageResultDto{Edge: edge, Bytes: data}
+		return
 	}
-	defer profileImageResp.Body.Close()
-
-	data, err := io.ReadAll(profileImageResp.Body)
 	if err != nil {
 		results <- SearchChannelsEdgeImageResultDto{Edge: edge, Err: err}
+		return
 	}
 
-	previewImageResp, err := http.Get(edge.Item.Stream.PreviewImageURL)
+	previewImageData, err := getSearchChannelImageData(edge.Item.Stream.PreviewImageURL)
 	if err != nil {
 		results <- SearchChannelsEdgeImageResultDto{Edge: edge, Err: err}
+		return
 	}
-	defer profileImageResp.Body.Close()
 
-	previewImageData, err := io.ReadAll(previewImageResp.Body)
+	results <- SearchChannelsEdgeImageResultDto{Edge: edge, Bytes: data, PreviewImageBytes: previewImageData}
+}
+
+func getSearchChannelImageData(url string) ([]byte, error) {
+	resp, err := http.Get(url)
 	if err != nil {
-		results <- SearchChannelsEdgeImageResultDto{Edge: edge, Err: err}
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	results <- SearchChannelsEdgeImageResultDto{Edge: edge, Bytes: data, PreviewImageBytes: previewImageData}
+	return io.ReadAll(resp.Body)
 }
 
 func getImageDataFromUrl(edge *TopChannelsEdgeGqlResponse, wg *sync.WaitGroup, results chan<- TopChannelEdgeImageResultDto) {
@@ -235,18 +364,48 @@ func getImageDataFromUrl(edge *TopChannelsEdgeGqlResponse, wg *sync.WaitGroup, r
 	results <- TopChannelEdgeImageResultDto{Edge: edge, Bytes: data, PreviewImageBytes: previewImageData}
 }
 
-func (s *TwitchService) GetStreamingUrl(channel string) (string, error) {
-	gqlResponse, err := s.getStreamingUrlGqlResponse(channel)
+func (s *TwitchService) GetStreamPlaylist(channel string) (*hls.MasterPlaylist, error) {
+	gqlResponse, err := s.getPlaybackAccessTokenGqlResponse(channel, "")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	usherResponse, nil := s.getUsherResponse(channel, gqlResponse)
 
-	return s.parseStreamUrlFromUsherResponse(usherResponse), nil
+	if gqlResponse.Data == nil || gqlResponse.Data.StreamPlaybackAccessToken == nil {
+		return nil, fmt.Errorf("no playback access token for channel: %s", channel)
+	}
+
+	playlistUrl := fmt.Sprintf("%s/%s.m3u8", s.Config.UsherUrl, strings.ToLower(channel))
+	return s.getUsherPlaylist(playlistUrl, gqlResponse.Data.StreamPlaybackAccessToken)
 }
 
-func (s *TwitchService) getStreamingUrlGqlResponse(channel string) (*StreamingUrlGqlResponse, error) {
-	gqlRequest, err := getStreamingLinkGqlRequest(channel)
+// GetVideoPlaylist returns the master playlist of a past broadcast or highlight, signed
+// with a video playback access token.
+func (s *TwitchService) GetVideoPlaylist(videoId string) (*hls.MasterPlaylist, error) {
+	gqlResponse, err := s.getPlaybackAccessTokenGqlResponse("", videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if gqlResponse.Data == nil || gqlResponse.Data.VideoPlaybackAccessToken == nil {
+		return nil, fmt.Errorf("no playback access token for video: %s", videoId)
+	}
+
+	playlistUrl := fmt.Sprintf("%s/%s.m3u8", s.Config.VodUsherUrl, videoId)
+	return s.getUsherPlaylist(playlistUrl, gqlResponse.Data.VideoPlaybackAccessToken)
+}
+
+// getTokenExpiry reads the unix "expires" time from the JSON value of a playback
+// access token, returning the zero time when the token has none.
+func getTokenExpiry(tokenValue string) time.Time {
+	var token PlaybackAccessTokenValue
+	if err := json.Unmarshal([]byte(tokenValue), &token); err != nil || token.Expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(token.Expires, 0)
+}
+
+func (s *TwitchService) getPlaybackAccessTokenGqlResponse(channel string, videoId string) (*StreamingUrlGqlResponse, error) {
+	gqlRequest, err := getPlaybackAccessTokenGqlRequest(channel, videoId)
 
 	if err != nil {
 		fmt.Println("Error creating request:", err)
@@ -285,13 +444,17 @@ func (s *TwitchService) getStreamingUrlGqlResponse(channel string) (*StreamingUr
 	return &parsedResponse, nil
 }
 
-func getStreamingLinkGqlRequest(channel string) (*bytes.Buffer, error) {
+// getPlaybackAccessTokenGqlRequest asks for the access token of the live stream of the
+// channel, or of the video when a video id is given.
+func getPlaybackAccessTokenGqlRequest(channel string, videoId string) (*bytes.Buffer, error) {
 	gqlRequest := &GqlRequest{
 		OperationName: "PlaybackAccessToken",
-		Query:         "query PlaybackAccessToken($login: String!, $isLive: Boolean!, $playerType: String!) { streamPlaybackAccessToken(channelName: $login, params: {platform: \"web\", playerBackend: \"mediaplayer\", playerType: $playerType}) @include(if: $isLive) { value signature } }",
+		Query:         "query PlaybackAccessToken($login: String!, $isLive: Boolean!, $vodID: ID!, $isVod: Boolean!, $playerType: String!) { streamPlaybackAccessToken(channelName: $login, params: {platform: \"web\", playerBackend: \"mediaplayer\", playerType: $playerType}) @include(if: $isLive) { value signature } videoPlaybackAccessToken(id: $vodID, params: {platform: \"web\", playerBackend: \"mediaplayer\", playerType: $playerType}) @include(if: $isVod) { value signature } }",
 		Variables: &GqlRequestVariables{
-			IsLive:     true,
+			IsLive:     videoId == "",
 			Login:      channel,
+			IsVod:      videoId != "",
+			VodId:      videoId,
 			PlayerType: "embed",
 		},
 	}
@@ -306,37 +469,42 @@ func getStreamingLinkGqlRequest(channel string) (*bytes.Buffer, error) {
 	return bytes.NewBuffer(gqlRequestJson), nil
 }
 
-func (s *TwitchService) getUsherResponse(channel string, gqlResponse *StreamingUrlGqlResponse) (string, error) {
-	encodedToken := url.QueryEscape(gqlResponse.Data.StreamPlaybackAccessToken.Value)
-	requestUrl := fmt.Sprintf("%s/%s.m3u8?sig=%s&token=%s", s.Config.UsherUrl, strings.ToLower(channel), gqlResponse.Data.StreamPlaybackAccessToken.Signature, encodedToken)
+// getUsherPlaylist fetches and parses a master playlist from usher, the playlist
+// expiring with its access token.
+func (s *TwitchService) getUsherPlaylist(playlistUrl string, token *StreamPlaybackAccessToken) (*hls.MasterPlaylist, error) {
+	encodedToken := url.QueryEscape(token.Value)
+	requestUrl := fmt.Sprintf("%s?sig=%s&token=%s&allow_source=true", playlistUrl, token.Signature, encodedToken)
 
 	req, err := http.NewRequest("GET", requestUrl, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	resp, err := s.Config.HttpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	return string(body), nil
-}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("usher responded with status %d for: %s", resp.StatusCode, playlistUrl)
+	}
 
-func (s *TwitchService) parseStreamUrlFromUsherResponse(response string) string {
-	rows := strings.Split(response, "\n")
-	for i, row := range rows {
-		if strings.Contains(row, s.Config.StreamResolution) {
-			return rows[i+1]
-		}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist, err := hls.ParseMasterPlaylist(string(body))
+	if err != nil {
+		return nil, err
 	}
 
-	return ""
+	playlist.ExpiresAt = getTokenExpiry(token.Value)
+	return playlist, nil
 }
 
-func (s *TwitchService) getTopChannelsGqlRequest(limit int) (*bytes.Buffer, error) {
+func (s *TwitchService) getTopChannelsGqlRequest(limit int, cursor string) (*bytes.Buffer, error) {
 	gqlRequest := &GqlRequest{
 		OperationName: "BrowsePage_Popular",
 		Variables: &GqlRequestVariables{
@@ -356,6 +524,7 @@ func (s *TwitchService) getTopChannelsGqlRequest(limit int) (*bytes.Buffer, erro
 			},
 			SortTypeIsRecency: false,
 			IncludeIsDJ:       true,
+			Cursor:            cursor,
 		},
 		Extensions: &GqlRequestExtensions{
 			PersistedQuery: &GqlRequestPersistedQuery{
@@ -375,12 +544,20 @@ func (s *TwitchService) getTopChannelsGqlRequest(limit int) (*bytes.Buffer, erro
 	return bytes.NewBuffer(gqlRequestJson), nil
 }
 
-func (s *TwitchService) getSearchChannelsGqlRequest(searchValue *string) (*bytes.Buffer, error) {
+func (s *TwitchService) getSearchChannelsGqlRequest(searchValue *string, cursor string) (*bytes.Buffer, error) {
+	var options *GqlRequestOptions
+	if cursor != "" {
+		options = &GqlRequestOptions{
+			Targets: []*GqlRequestSearchTarget{{Index: "CHANNEL", Cursor: cursor}},
+		}
+	}
+
 	gqlRequest := &GqlRequest{
 		OperationName: "SearchResultsPage_SearchResults",
 		Variables: &GqlRequestVariables{
 			Query:       *searchValue,
 			IncludeIsDJ: true,
+			Options:     options,
 		},
 		Extensions: &GqlRequestExtensions{
 			PersistedQuery: &GqlRequestPersistedQuery{
@@ -399,3 +576,35 @@ func (s *TwitchService) getSearchChannelsGqlRequest(searchValue *string) (*bytes
 
 	return bytes.NewBuffer(gqlRequestJson), nil
 }
+
+func (s *TwitchService) executeGqlRequest(gqlRequest *GqlRequest, response any) error {
+	gqlRequestJson, err := json.Marshal(gqlRequest)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.Config.GqlUrl, bytes.NewBuffer(gqlRequestJson))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Client-Id", s.Config.ClientId)
+
+	gqlResponse, err := s.Config.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer gqlResponse.Body.Close()
+
+	if gqlResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("gql request %s failed with status %d", gqlRequest.OperationName, gqlResponse.StatusCode)
+	}
+
+	gqlBody, err := io.ReadAll(gqlResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(gqlBody, response)
+}
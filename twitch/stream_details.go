@@ -0,0 +1,70 @@
+package twitch
+
+import (
+	"fmt"
+
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+// GetStreamDetails returns the live stream of the channel with its category, tags,
+// language, start time and follower count. The preview image is requested in the
+// larger details size.
+func (s *TwitchService) GetStreamDetails(login string) (*model.StreamDetails, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "StreamDetails",
+		Query:         "query StreamDetails($login: String!, $imageWidth: Int!, $previewWidth: Int!, $previewHeight: Int!) { user(login: $login) { id login displayName profileImageURL(width: $imageWidth) followers { totalCount } broadcastSettings { language } stream { id title type viewersCount createdAt previewImageUrl: previewImageURL(width: $previewWidth, height: $previewHeight) game { id name displayName } freeformTags { name } } } }",
+		Variables: &GqlRequestVariables{
+			Login:         login,
+			ImageWidth:    50,
+			PreviewWidth:  s.Config.DetailsPreviewImageWidth,
+			PreviewHeight: s.Config.DetailsPreviewImageHeight,
+		},
+	}
+
+	var parsedResponse StreamDetailsGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.User == nil {
+		return nil, fmt.Errorf("channel not found: %s", login)
+	}
+
+	user := parsedResponse.Data.User
+	if user.Stream == nil {
+		return nil, fmt.Errorf("channel is offline: %s", login)
+	}
+
+	details := &model.StreamDetails{
+		Stream: &model.Stream{
+			Id:              user.Stream.Id,
+			Title:           user.Stream.Title,
+			ViewersCount:    user.Stream.ViewersCount,
+			PreviewImageURL: user.Stream.PreviewImageURL,
+			Broadcaster: &model.Broadcaster{
+				Provider:        ProviderName,
+				Id:              user.Id,
+				Login:           user.Login,
+				DisplayName:     user.DisplayName,
+				ProfileImageURL: user.ProfileImageURL,
+			},
+		},
+		Tags:      make([]string, 0, len(user.Stream.FreeformTags)),
+		StartedAt: user.Stream.CreatedAt,
+	}
+
+	if user.Stream.Game != nil {
+		details.Category = user.Stream.Game.DisplayName
+	}
+	for _, tag := range user.Stream.FreeformTags {
+		details.Tags = append(details.Tags, tag.Name)
+	}
+	if user.BroadcastSettings != nil {
+		details.Language = user.BroadcastSettings.Language
+	}
+	if user.Followers != nil {
+		details.FollowersCount = user.Followers.TotalCount
+	}
+
+	return details, nil
+}
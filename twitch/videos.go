@@ -0,0 +1,67 @@
+package twitch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/model"
+)
+
+// GetVideos returns the latest past broadcasts and highlights of the channel without
+// image data, the images are expected to be loaded through the ImageDataService.
+func (s *TwitchService) GetVideos(login string) ([]model.Video, error) {
+	gqlRequest := &GqlRequest{
+		OperationName: "ChannelVideos",
+		Query:         "query ChannelVideos($login: String!, $limit: Int!, $imageWidth: Int!, $previewWidth: Int!, $previewHeight: Int!) { user(login: $login) { id login displayName profileImageURL(width: $imageWidth) videos(first: $limit, sort: TIME, types: [ARCHIVE, HIGHLIGHT]) { edges { node { id title lengthSeconds publishedAt broadcastType viewCount previewThumbnailURL(width: $previewWidth, height: $previewHeight) } } } } }",
+		Variables: &GqlRequestVariables{
+			Login:         login,
+			Limit:         s.Config.VideosLimit,
+			ImageWidth:    50,
+			PreviewWidth:  s.Config.PreviewImageWidth,
+			PreviewHeight: s.Config.PreviewImageHeight,
+		},
+	}
+
+	var parsedResponse ChannelVideosGqlResponse
+	if err := s.executeGqlRequest(gqlRequest, &parsedResponse); err != nil {
+		return nil, err
+	}
+
+	if parsedResponse.Data == nil || parsedResponse.Data.User == nil {
+		return nil, fmt.Errorf("channel not found: %s", login)
+	}
+
+	user := parsedResponse.Data.User
+	broadcaster := &model.Broadcaster{
+		Provider:        ProviderName,
+		Id:              user.Id,
+		Login:           user.Login,
+		DisplayName:     user.DisplayName,
+		ProfileImageURL: user.ProfileImageURL,
+	}
+
+	videos := make([]model.Video, 0)
+	if user.Videos == nil {
+		return videos, nil
+	}
+
+	for _, edge := range user.Videos.Edges {
+		if edge.Node == nil {
+			continue
+		}
+
+		videos = append(videos, model.Video{
+			Id:              edge.Node.Id,
+			Title:           edge.Node.Title,
+			Type:            strings.ToLower(edge.Node.BroadcastType),
+			Duration:        time.Duration(edge.Node.LengthSeconds) * time.Second,
+			PublishedAt:     edge.Node.PublishedAt,
+			ViewsCount:      edge.Node.ViewCount,
+			PreviewImageURL: edge.Node.PreviewThumbnailURL,
+			Broadcaster:     broadcaster,
+		})
+	}
+
+	return videos, nil
+}
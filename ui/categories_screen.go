@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"log"
+	"math"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
+)
+
+type CategoriesScreen struct {
+	SelectedCategory int
+	PageStartIndex   int
+	PageEndIndex     int
+	Categories       []model.Category
+	CategoryProvider provider.CategoryProvider
+	Player           *player.Player
+}
+
+func CreateCategoriesScreen(app *app.App, categoryProvider provider.CategoryProvider, mediaPlayer *player.Player) *CategoriesScreen {
+	categories, err := categoryProvider.GetTopCategories()
+	if err != nil {
+		log.Printf("An error occurred while fetching top categories: %v", err)
+		categories = make([]model.Category, 0)
+	}
+
+	imageUrls := make([]string, 0, len(categories))
+	for _, category := range categories {
+		imageUrls = append(imageUrls, category.BoxArtURL)
+	}
+
+	imageData := app.ImageDataService.GetImageData(imageUrls)
+	result := make([]model.Category, len(categories))
+	for i := 0; i < len(categories); i++ {
+		result[i] = categories[i].WithImageData(imageData[categories[i].BoxArtURL])
+	}
+
+	return &CategoriesScreen{
+		Categories:       result,
+		CategoryProvider: categoryProvider,
+		PageStartIndex:   0,
+		PageEndIndex:     int(math.Min(float64(2), float64(len(result)-1))),
+		Player:           mediaPlayer,
+	}
+}
+
+func (s *CategoriesScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp()
+	case input.Down:
+		s.handleKeyDown()
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B, input.Right:
+		s.handleKeyB(appState)
+	case input.X:
+		s.handleKeyX(appState)
+	}
+}
+
+func (s *CategoriesScreen) handleKeyUp() {
+	if s.SelectedCategory <= 0 {
+		return
+	}
+
+	s.SelectedCategory--
+	if s.SelectedCategory < s.PageStartIndex {
+		s.PageStartIndex--
+		s.PageEndIndex--
+	}
+}
+
+func (s *CategoriesScreen) handleKeyDown() {
+	if s.SelectedCategory >= len(s.Categories)-1 {
+		return
+	}
+
+	s.SelectedCategory++
+	if s.SelectedCategory > s.PageEndIndex {
+		s.PageStartIndex++
+		s.PageEndIndex = int(math.Min(float64(s.PageEndIndex+1), float64(len(s.Categories)-1)))
+	}
+}
+
+func (s *CategoriesScreen) handleKeyA(app *app.App) {
+	if len(s.Categories) == 0 {
+		return
+	}
+
+	category := s.Categories[s.SelectedCategory]
+	app.StartLoading("Loading " + category.DisplayName + " streams...")
+	go func() {
+		app.State = CreateCategoryStreamsScreen(app, s, category, s.Player)
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func (s *CategoriesScreen) handleKeyB(app *app.App) {
+	app.State = CreateMainScreen(s.Player)
+}
+
+func (s *CategoriesScreen) handleKeyX(app *app.App) {
+	app.State = CreateSearchScreen(app, s.Player)
+}
+
+func (s *CategoriesScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	DrawCategories(app, s.Categories, s.PageStartIndex, s.PageEndIndex, s.SelectedCategory)
+}
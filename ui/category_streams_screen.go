@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"log"
+	"math"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
+)
+
+type CategoryStreamsScreen struct {
+	SelectedStream int
+	PageStartIndex int
+	PageEndIndex   int
+	Category       model.Category
+	Streams        []model.Stream
+	Categories     *CategoriesScreen
+	Player         *player.Player
+}
+
+func CreateCategoryStreamsScreen(app *app.App, categories *CategoriesScreen, category model.Category, mediaPlayer *player.Player) *CategoryStreamsScreen {
+	streams, err := categories.CategoryProvider.GetCategoryStreams(category.Name)
+	if err != nil {
+		log.Printf("An error occurred while fetching streams for category: %s, %v", category.Name, err)
+		streams = make([]model.Stream, 0)
+	}
+
+	result := app.LoadStreamImages(streams)
+
+	return &CategoryStreamsScreen{
+		Category:       category,
+		Streams:        result,
+		PageStartIndex: 0,
+		PageEndIndex:   int(math.Min(float64(2), float64(len(result)-1))),
+		Categories:     categories,
+		Player:         mediaPlayer,
+	}
+}
+
+func (s *CategoryStreamsScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp()
+	case input.Down:
+		s.handleKeyDown()
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	case input.X:
+		s.handleKeyX(appState)
+	case input.Y:
+		s.handleKeyY(appState)
+	case input.L1:
+		s.handleKeyL1(appState)
+	case input.R1:
+		s.handleKeyR1(appState)
+	case input.Start:
+		s.handleKeyStart(appState)
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyY(appState *app.App) {
+	if len(s.Streams) > 0 {
+		appState.UserDataManager.ToggleFavoriteBroadcaster(s.Streams[s.SelectedStream].Broadcaster)
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyX(appState *app.App) {
+	if !s.Player.IsInForeground() {
+		appState.State = CreateSearchScreen(appState, s.Player)
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyB(app *app.App) {
+	if s.Player.IsInForeground() {
+		s.Player.Stop()
+		app.StopChat()
+		app.FinishLoading()
+		app.RaiseAppWindow()
+	} else {
+		app.State = s.Categories
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyA(app *app.App) {
+	if s.Player.IsInForeground() || len(s.Streams) == 0 {
+		return
+	}
+
+	OpenStreamDetailsScreen(app, s, s.Streams[s.SelectedStream], s.Player)
+}
+
+func (s *CategoryStreamsScreen) handleKeyDown() {
+	if s.Player.IsInForeground() || s.SelectedStream >= len(s.Streams)-1 {
+		return
+	}
+
+	s.SelectedStream++
+	if s.SelectedStream > s.PageEndIndex {
+		s.PageStartIndex++
+		s.PageEndIndex = int(math.Min(float64(s.PageEndIndex+1), float64(len(s.Streams)-1)))
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyUp() {
+	if s.Player.IsInForeground() || s.SelectedStream <= 0 {
+		return
+	}
+
+	s.SelectedStream--
+	if s.SelectedStream < s.PageStartIndex {
+		s.PageStartIndex--
+		s.PageEndIndex--
+	}
+}
+
+func (s *CategoryStreamsScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	DrawStreams(app, s.Streams, s.PageStartIndex, s.PageEndIndex, s.SelectedStream)
+}
+
+func (s *CategoryStreamsScreen) handleKeyL1(app *app.App) {
+	if !s.Player.IsInForeground() && len(s.Streams) > 0 {
+		toggleMultiViewStream(app, &s.Streams[s.SelectedStream])
+	}
+}
+
+func (s *CategoryStreamsScreen) handleKeyR1(app *app.App) {
+	if !s.Player.IsInForeground() {
+		startMultiView(app, s.Player)
+	}
+}
+
+// handleKeyStart opens the top clips of the category when the provider keeps clips.
+// BindsStart reports whether Start opens the clips of the category.
+func (s *CategoryStreamsScreen) BindsStart() bool {
+	_, supported := s.Categories.CategoryProvider.(provider.ClipProvider)
+	return supported && !s.Player.IsInForeground()
+}
+
+func (s *CategoryStreamsScreen) handleKeyStart(app *app.App) {
+	if s.Player.IsInForeground() {
+		return
+	}
+	if clipProvider, supported := s.Categories.CategoryProvider.(provider.ClipProvider); supported {
+		OpenCategoryClipsScreen(app, s, s.Category, clipProvider, s.Player)
+	}
+}
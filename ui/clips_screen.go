@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// clipsPerPage leaves room for the period tabs above the clips
+const clipsPerPage = 2
+
+type ClipsScreen struct {
+	Previous       app.Screen
+	Name           string
+	ClipProvider   provider.ClipProvider
+	Period         model.ClipPeriod
+	Clips          []model.Video
+	SelectedClip   int
+	PageStartIndex int
+	PageEndIndex   int
+	Player         *player.Player
+	// getClips fetches the clips of the broadcaster or category over a period
+	getClips func(period model.ClipPeriod) ([]model.Video, error)
+}
+
+// OpenBroadcasterClipsScreen lists the top clips of the broadcaster.
+func OpenBroadcasterClipsScreen(app *app.App, previous app.Screen, broadcaster *model.Broadcaster, clipProvider provider.ClipProvider, mediaPlayer *player.Player) {
+	s := CreateClipsScreen(previous, broadcaster.DisplayName, clipProvider, mediaPlayer, func(period model.ClipPeriod) ([]model.Video, error) {
+		return clipProvider.GetBroadcasterClips(broadcaster.Login, period)
+	})
+	s.loadClips(app)
+}
+
+// OpenCategoryClipsScreen lists the top clips of the category.
+func OpenCategoryClipsScreen(app *app.App, previous app.Screen, category model.Category, clipProvider provider.ClipProvider, mediaPlayer *player.Player) {
+	s := CreateClipsScreen(previous, category.DisplayName, clipProvider, mediaPlayer, func(period model.ClipPeriod) ([]model.Video, error) {
+		return clipProvider.GetCategoryClips(category.Name, period)
+	})
+	s.loadClips(app)
+}
+
+func CreateClipsScreen(previous app.Screen, name string, clipProvider provider.ClipProvider, mediaPlayer *player.Player, getClips func(period model.ClipPeriod) ([]model.Video, error)) *ClipsScreen {
+	return &ClipsScreen{
+		Previous:     previous,
+		Name:         name,
+		ClipProvider: clipProvider,
+		Period:       model.ClipPeriodWeek,
+		Clips:        make([]model.Video, 0),
+		PageEndIndex: -1,
+		Player:       mediaPlayer,
+		getClips:     getClips,
+	}
+}
+
+// loadClips fetches the clips of the selected period and their thumbnails in the
+// background, the screen is shown once they are loaded.
+func (s *ClipsScreen) loadClips(app *app.App) {
+	period := s.Period
+	loadingText := "Loading " + s.Name + " clips (" + period.String() + ")..."
+	app.StartLoading(loadingText)
+	go func() {
+		clips, err := s.getClips(period)
+		if err != nil {
+			log.Printf("An error occurred while fetching clips for: %s, %v", s.Name, err)
+			app.ShowToast("Could not load clips: " + err.Error())
+			clips = make([]model.Video, 0)
+		}
+		clips = loadVideoImages(app, clips)
+
+		// The user may have moved on to another screen while the clips were loading
+		if app.State != s && app.State != s.Previous {
+			if app.LoadingText == loadingText {
+				app.FinishLoading()
+			}
+			app.NeedsRedraw = true
+			return
+		}
+
+		s.Clips = clips
+		s.SelectedClip = 0
+		s.PageStartIndex = 0
+		s.PageEndIndex = min(clipsPerPage-1, len(s.Clips)-1)
+		app.State = s
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func (s *ClipsScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp()
+	case input.Down:
+		s.handleKeyDown()
+	case input.Left:
+		s.handleKeyLeft(appState)
+	case input.Right:
+		s.handleKeyRight(appState)
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	}
+}
+
+func (s *ClipsScreen) handleKeyDown() {
+	if s.Player.IsInForeground() || s.SelectedClip >= len(s.Clips)-1 {
+		return
+	}
+
+	s.SelectedClip++
+	if s.SelectedClip > s.PageEndIndex {
+		s.PageStartIndex++
+		s.PageEndIndex = min(s.PageEndIndex+1, len(s.Clips)-1)
+	}
+}
+
+func (s *ClipsScreen) handleKeyUp() {
+	if s.Player.IsInForeground() || s.SelectedClip <= 0 {
+		return
+	}
+
+	s.SelectedClip--
+	if s.SelectedClip < s.PageStartIndex {
+		s.PageStartIndex--
+		s.PageEndIndex--
+	}
+}
+
+// handleKeyLeft and handleKeyRight switch to a shorter or longer period.
+func (s *ClipsScreen) handleKeyLeft(app *app.App) {
+	if s.Player.IsInForeground() || s.Period <= model.ClipPeriods[0] {
+		return
+	}
+	s.Period--
+	s.loadClips(app)
+}
+
+func (s *ClipsScreen) handleKeyRight(app *app.App) {
+	if s.Player.IsInForeground() || s.Period >= model.ClipPeriods[len(model.ClipPeriods)-1] {
+		return
+	}
+	s.Period++
+	s.loadClips(app)
+}
+
+// handleKeyA plays the selected clip from its video file in the preferred quality.
+func (s *ClipsScreen) handleKeyA(app *app.App) {
+	if s.Player.IsInForeground() || len(s.Clips) == 0 {
+		return
+	}
+
+	clip := &s.Clips[s.SelectedClip]
+	app.StopChat()
+	app.StartLoading("Loading " + truncateText(clip.Title, app.Config.UI.StreamsUiConfig.MaxTitleLength) + "...")
+	go func() {
+		variants, err := s.ClipProvider.GetClipVariants(clip.Id)
+		var selection *hls.Selection
+		if err == nil {
+			selection, err = hls.SelectVariant(variants, app.Config.Player.StreamQualityPreferences)
+		}
+		if err == nil {
+			s.Player.ShowChat = false
+			err = s.Player.PlayVideo(clip, selection.Variant, 0)
+		}
+		if err != nil {
+			log.Printf("An error occurred while playing clip: %s, %v", clip.Id, err)
+			app.FinishLoading()
+			app.ShowToast("Could not play clip: " + err.Error())
+			return
+		}
+		app.LoadingText = "Playing " + clip.Title + " (" + selection.Variant.Quality() + ")"
+	}()
+}
+
+func (s *ClipsScreen) handleKeyB(app *app.App) {
+	if s.Player.IsInForeground() {
+		s.Player.Stop()
+		app.FinishLoading()
+		app.RaiseAppWindow()
+		return
+	}
+
+	app.State = s.Previous
+	app.NeedsRedraw = true
+}
+
+func (s *ClipsScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	y := s.drawPeriods(app)
+	if len(s.Clips) == 0 {
+		app.DrawCenteredText("No clips of "+s.Name+" in this period.", app.Config.UI.Colors.NoResultsTextColor)
+		return
+	}
+
+	for i := s.PageStartIndex; i <= s.PageEndIndex; i++ {
+		drawVideo(&s.Clips[i], app, app.Config.UI.StreamLeftMargin, y, i == s.SelectedClip)
+		y += app.Config.UI.StreamsUiConfig.Height
+	}
+}
+
+// drawPeriods draws the periods as tabs under the header, the selected one highlighted,
+// and returns where the clips start.
+func (s *ClipsScreen) drawPeriods(app *app.App) int32 {
+	margin := app.Config.UI.StreamLeftMargin
+	rowHeight := app.Config.UI.OverlayUiConfig.RowHeight
+	gap := app.Config.UI.StreamsUiConfig.Padding
+	width := app.Config.UI.KeyWidth * 2
+	y := app.Config.UI.HeaderHeight + app.Config.UI.StreamsTopMargin/2
+
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	app.DrawText("Top clips of "+s.Name, app.Config.UI.Colors.StreamerNameTextColor, margin, y+(rowHeight-int32(app.Font.Height()))/2)
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+	x := app.Config.Display.Width - margin - int32(len(model.ClipPeriods))*(width+gap)
+	for _, period := range model.ClipPeriods {
+		tab := sdl.Rect{X: x, Y: y, W: width, H: rowHeight}
+		color := app.Config.UI.Colors.KeyColor
+		if period == s.Period {
+			app.FillRect(&tab, app.Config.UI.Colors.SelectedKeyBackgroundColor)
+			app.DrawRect(&tab, app.Config.UI.Colors.SelectedKeyBorderColor)
+			color = app.Config.UI.Colors.SelectedKeyColor
+		} else {
+			app.FillRect(&tab, app.Config.UI.Colors.KeyBackgroundColor)
+			app.DrawRect(&tab, app.Config.UI.Colors.KeyBorderColor)
+		}
+		app.DrawCenteredTextInRect(period.String(), &tab, color)
+		x += width + gap
+	}
+
+	return y + rowHeight + app.Config.UI.StreamsTopMargin/2
+}
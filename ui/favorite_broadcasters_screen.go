@@ -3,11 +3,15 @@ package ui
 import (
 	"log"
 	"math"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/fspasovski/pocketstream-app/app"
 	"github.com/fspasovski/pocketstream-app/input"
 	"github.com/fspasovski/pocketstream-app/model"
 	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/twitch"
 )
 
 type FavoriteBroadcastersScreen struct {
@@ -18,29 +22,77 @@ type FavoriteBroadcastersScreen struct {
 	Player         *player.Player
 }
 
-func CreateFavoriteBroadcastersScreen(app *app.App, mediaPlayer *player.Player) *FavoriteBroadcastersScreen {
+// CreateFavoriteBroadcastersScreen lists the favorites, failing when it cannot tell which
+// of the Twitch ones are live rather than showing them all offline.
+func CreateFavoriteBroadcastersScreen(app *app.App, mediaPlayer *player.Player) (*FavoriteBroadcastersScreen, error) {
 	if app.UserDataManager.NoFavoriteBroadcasters() {
 		return &FavoriteBroadcastersScreen{
 			Streams:        make([]model.Stream, 0),
 			PageStartIndex: 0,
 			PageEndIndex:   0,
 			Player:         mediaPlayer,
-		}
+		}, nil
 	}
 
 	favoriteBroadcasterLogins := make([]string, 0)
+	for _, broadcaster := range app.UserDataManager.GetFavoriteBroadcasters(twitch.ProviderName) {
+		favoriteBroadcasterLogins = append(favoriteBroadcasterLogins, broadcaster.Login)
+	}
 
-	for login := range app.UserDataManager.Data.FavoriteBroadcasters {
-		favoriteBroadcasterLogins = append(favoriteBroadcasterLogins, login)
+	favoriteStreams := make([]model.Stream, 0)
+	if len(favoriteBroadcasterLogins) > 0 {
+		var err error
+		favoriteStreams, err = app.PocketstreamService.FetchStreams(favoriteBroadcasterLogins)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	favoriteStreams := app.PocketstreamService.GetStreams(favoriteBroadcasterLogins)
+	// The Pocketstream API only knows Twitch, favorites of other providers are checked
+	// one by one, all at once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, streamProvider := range app.Config.Providers {
+		if streamProvider.Name() == twitch.ProviderName {
+			continue
+		}
+
+		for _, broadcaster := range app.UserDataManager.GetFavoriteBroadcasters(streamProvider.Name()) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				channel, err := streamProvider.GetChannelInfo(broadcaster.Login)
+				if err != nil {
+					log.Printf("An error occurred while fetching channel info for: %s, %v", broadcaster.Key(), err)
+					return
+				}
+				if channel.IsLive && channel.Stream != nil {
+					mu.Lock()
+					favoriteStreams = append(favoriteStreams, *channel.Stream)
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Live favorites come first, the most watched on top, followed by the offline ones
+	sort.SliceStable(favoriteStreams, func(i, j int) bool {
+		if favoriteStreams[i].ViewersCount != favoriteStreams[j].ViewersCount {
+			return favoriteStreams[i].ViewersCount > favoriteStreams[j].ViewersCount
+		}
+		return strings.ToLower(favoriteStreams[i].Broadcaster.Login) < strings.ToLower(favoriteStreams[j].Broadcaster.Login)
+	})
+	favoriteStreams = append(favoriteStreams, getOfflineFavoriteStreams(app, favoriteStreams)...)
 
 	imageUrls := make([]string, 0)
 
 	for _, stream := range favoriteStreams {
-		imageUrls = append(imageUrls, stream.PreviewImageURL)
-		imageUrls = append(imageUrls, app.UserDataManager.GetBroadcasterImageUrl(stream.Broadcaster.Login))
+		if stream.PreviewImageURL != "" {
+			imageUrls = append(imageUrls, stream.PreviewImageURL)
+		}
+		imageUrls = append(imageUrls, app.UserDataManager.GetBroadcasterImageUrl(stream.Broadcaster))
 	}
 
 	imageData := app.ImageDataService.GetImageData(imageUrls)
@@ -48,7 +100,7 @@ func CreateFavoriteBroadcastersScreen(app *app.App, mediaPlayer *player.Player)
 	for i := 0; i < len(favoriteStreams); i++ {
 		result[i] = favoriteStreams[i].WithImageData(
 			imageData[favoriteStreams[i].PreviewImageURL],
-			imageData[app.UserDataManager.GetBroadcasterImageUrl(favoriteStreams[i].Broadcaster.Login)],
+			imageData[app.UserDataManager.GetBroadcasterImageUrl(favoriteStreams[i].Broadcaster)],
 		)
 	}
 
@@ -57,7 +109,32 @@ func CreateFavoriteBroadcastersScreen(app *app.App, mediaPlayer *player.Player)
 		PageStartIndex: 0,
 		PageEndIndex:   int(math.Min(float64(2), float64(len(favoriteStreams)-1))),
 		Player:         mediaPlayer,
+	}, nil
+}
+
+// getOfflineFavoriteStreams returns the favorites without a live stream as offline
+// streams ordered by name, drawn from what was saved when they were favorited.
+func getOfflineFavoriteStreams(app *app.App, liveStreams []model.Stream) []model.Stream {
+	live := make(map[string]bool, len(liveStreams))
+	for _, stream := range liveStreams {
+		live[stream.Broadcaster.Key()] = true
 	}
+
+	offlineStreams := make([]model.Stream, 0)
+	for _, broadcaster := range app.UserDataManager.Data.FavoriteBroadcasters {
+		if live[broadcaster.Key()] {
+			continue
+		}
+		offlineStreams = append(offlineStreams, model.Stream{
+			Broadcaster: broadcaster.WithImageData(nil),
+			Offline:     true,
+		})
+	}
+
+	sort.Slice(offlineStreams, func(i, j int) bool {
+		return strings.ToLower(offlineStreams[i].Broadcaster.Login) < strings.ToLower(offlineStreams[j].Broadcaster.Login)
+	})
+	return offlineStreams
 }
 
 func (s *FavoriteBroadcastersScreen) HandleInput(appState *app.App, key input.Key) {
@@ -76,6 +153,10 @@ func (s *FavoriteBroadcastersScreen) HandleInput(appState *app.App, key input.Ke
 		s.handleKeyY(appState)
 	case input.Left:
 		s.handleKeyRight(appState)
+	case input.L1:
+		s.handleKeyL1(appState)
+	case input.R1:
+		s.handleKeyR1(appState)
 	}
 }
 
@@ -86,8 +167,9 @@ func (s *FavoriteBroadcastersScreen) handleKeyY(appState *app.App) {
 }
 
 func (s *FavoriteBroadcastersScreen) handleKeyB(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() {
 		s.Player.Stop()
+		app.StopChat()
 		app.FinishLoading()
 		app.RaiseAppWindow()
 	} else {
@@ -96,22 +178,15 @@ func (s *FavoriteBroadcastersScreen) handleKeyB(app *app.App) {
 }
 
 func (s *FavoriteBroadcastersScreen) handleKeyA(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() || len(s.Streams) == 0 {
 		return
 	}
 
-	app.StartLoading("Loading " + s.Streams[s.SelectedStream].Broadcaster.Login + " stream...")
-	go func() {
-		err := s.Player.Play(s.Streams[s.SelectedStream].Broadcaster.Login)
-		if err != nil {
-			log.Printf("An error occurred while playing stream: %v", err)
-			app.FinishLoading()
-		}
-	}()
+	OpenStreamDetailsScreen(app, s, s.Streams[s.SelectedStream], s.Player)
 }
 
 func (s *FavoriteBroadcastersScreen) handleKeyDown() {
-	if s.Player.IsPlaying() || s.SelectedStream >= len(s.Streams)-1 {
+	if s.Player.IsInForeground() || s.SelectedStream >= len(s.Streams)-1 {
 		return
 	}
 
@@ -123,7 +198,7 @@ func (s *FavoriteBroadcastersScreen) handleKeyDown() {
 }
 
 func (s *FavoriteBroadcastersScreen) handleKeyUp() {
-	if s.Player.IsPlaying() || s.SelectedStream <= 0 {
+	if s.Player.IsInForeground() || s.SelectedStream <= 0 {
 		return
 	}
 
@@ -150,7 +225,19 @@ func (s *FavoriteBroadcastersScreen) handleKeyRight(app *app.App) {
 }
 
 func (s *FavoriteBroadcastersScreen) handleKeyX(appState *app.App) {
-	if !s.Player.IsPlaying() {
+	if !s.Player.IsInForeground() {
 		appState.State = CreateSearchScreen(appState, s.Player)
 	}
 }
+
+func (s *FavoriteBroadcastersScreen) handleKeyL1(app *app.App) {
+	if !s.Player.IsInForeground() && len(s.Streams) > 0 {
+		toggleMultiViewStream(app, &s.Streams[s.SelectedStream])
+	}
+}
+
+func (s *FavoriteBroadcastersScreen) handleKeyR1(app *app.App) {
+	if !s.Player.IsInForeground() {
+		startMultiView(app, s.Player)
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/twitch"
+)
+
+// HandleGoLiveKey watches the favorite announced by a go-live toast when Start is
+// pressed while it shows, and reports whether the key was used. Screens binding Start
+// themselves keep it.
+func HandleGoLiveKey(app *app.App, mediaPlayer *player.Player, key input.Key) bool {
+	stream := app.GoLiveShortcut()
+	if key != input.Start || stream == nil {
+		return false
+	}
+
+	broadcaster := stream.Broadcaster
+	app.ToastText = ""
+	app.GoLive = nil
+	app.StopChat()
+	app.StartLoading("Loading " + broadcaster.Login + " stream...")
+	mediaPlayer.ShowChat = app.UserDataManager.Data.ShowChat
+	go func() {
+		selection, err := mediaPlayer.Play(broadcaster)
+		if err != nil {
+			log.Printf("An error occurred while playing stream: %v", err)
+			app.FinishLoading()
+			app.ShowToast("Could not play " + broadcaster.Login + ": " + err.Error())
+			return
+		}
+
+		if selection.Variant.IsAudioOnly {
+			app.FinishLoading()
+			app.ShowToast("Listening to " + broadcaster.Login)
+			return
+		}
+		app.LoadingText = selection.Description()
+		if mediaPlayer.ShowChat && broadcaster.Provider == twitch.ProviderName {
+			app.StartChat(broadcaster.Login)
+		}
+	}()
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/fspasovski/pocketstream-app/app"
 	"github.com/fspasovski/pocketstream-app/input"
 	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
 )
 
 type MainScreen struct {
@@ -36,11 +37,21 @@ func (s *MainScreen) HandleInput(appState *app.App, key input.Key) {
 		s.handleKeyY(appState)
 	case input.Right:
 		s.handleKeyRight(appState)
+	case input.Left:
+		s.handleKeyLeft(appState)
+	case input.Select:
+		s.handleKeySelect(appState)
+	case input.Start:
+		s.handleKeyStart(appState)
+	case input.L1:
+		s.handleKeyL1(appState)
+	case input.R1:
+		s.handleKeyR1(appState)
 	}
 }
 
 func (s *MainScreen) handleKeyUp() {
-	if s.Player.IsPlaying() || s.SelectedStream <= 0 {
+	if s.Player.IsInForeground() || s.SelectedStream <= 0 {
 		return
 	}
 
@@ -52,7 +63,7 @@ func (s *MainScreen) handleKeyUp() {
 }
 
 func (s *MainScreen) handleKeyDown(appState *app.App) {
-	if s.Player.IsPlaying() || s.SelectedStream >= len(appState.TopStreams)-1 {
+	if s.Player.IsInForeground() || s.SelectedStream >= len(appState.TopStreams)-1 {
 		return
 	}
 
@@ -61,35 +72,35 @@ func (s *MainScreen) handleKeyDown(appState *app.App) {
 		s.PageStartIndex++
 		s.PageEndIndex = int(math.Min(float64(s.PageEndIndex+1), float64(len(appState.TopStreams)-1)))
 	}
+	if s.SelectedStream == len(appState.TopStreams)-1 {
+		appState.LoadMoreTopStreams()
+	}
 }
 
 func (s *MainScreen) handleKeyA(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() || len(app.TopStreams) == 0 {
 		return
 	}
 
-	app.StartLoading("Loading " + app.TopStreams[s.SelectedStream].Broadcaster.Login + " stream...")
-	go func() {
-		err := s.Player.Play(app.TopStreams[s.SelectedStream].Broadcaster.Login)
-		if err != nil {
-			log.Printf("An error occurred while playing stream: %v", err)
-			app.FinishLoading()
-		}
-	}()
+	OpenStreamDetailsScreen(app, s, app.TopStreams[s.SelectedStream], s.Player)
 }
 
 func (s *MainScreen) handleKeyB(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() {
 		s.Player.Stop()
+		app.StopChat()
 		app.FinishLoading()
 		app.RaiseAppWindow()
+	} else if s.Player.IsPlaying() {
+		// Stop listening in the background before B quits the app
+		s.Player.Stop()
 	} else {
 		app.Running = false
 	}
 }
 
 func (s *MainScreen) handleKeyX(appState *app.App) {
-	if !s.Player.IsPlaying() {
+	if !s.Player.IsInForeground() {
 		appState.State = CreateSearchScreen(appState, s.Player)
 	}
 }
@@ -108,14 +119,69 @@ func (s *MainScreen) Draw(app *app.App) {
 func (s *MainScreen) handleKeyRight(app *app.App) {
 	app.StartLoading("Loading favorite streams...")
 	go func() {
-		app.State = CreateFavoriteBroadcastersScreen(app, s.Player)
+		favoritesScreen, err := CreateFavoriteBroadcastersScreen(app, s.Player)
+		if err != nil {
+			log.Printf("An error occurred while fetching favorite streams: %v", err)
+			app.ShowToast("Could not load favorite streams: " + err.Error())
+		} else {
+			app.State = favoritesScreen
+		}
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func (s *MainScreen) handleKeyLeft(app *app.App) {
+	categoryProvider, supported := app.Provider.(provider.CategoryProvider)
+	if s.Player.IsInForeground() || !supported {
+		return
+	}
+
+	app.StartLoading("Loading categories...")
+	go func() {
+		app.State = CreateCategoriesScreen(app, categoryProvider, s.Player)
 		app.FinishLoading()
 		app.NeedsRedraw = true
 	}()
 }
 
+func (s *MainScreen) handleKeySelect(app *app.App) {
+	if s.Player.IsInForeground() || len(app.Config.Providers) < 2 {
+		return
+	}
+
+	s.SelectedStream = 0
+	s.PageStartIndex = 0
+	s.PageEndIndex = 2
+	app.SwitchProvider()
+}
+
+// BindsStart reports whether Start opens the recordings, which it does unless a stream
+// is watched.
+func (s *MainScreen) BindsStart() bool {
+	return !s.Player.IsInForeground()
+}
+
+func (s *MainScreen) handleKeyStart(app *app.App) {
+	if !s.Player.IsInForeground() {
+		OpenRecordingsScreen(app, s, s.Player)
+	}
+}
+
 func (s *MainScreen) handleKeyY(app *app.App) {
 	if len(app.TopStreams) > 0 {
 		app.UserDataManager.ToggleFavoriteBroadcaster(app.TopStreams[s.SelectedStream].Broadcaster)
 	}
 }
+
+func (s *MainScreen) handleKeyL1(app *app.App) {
+	if !s.Player.IsInForeground() && len(app.TopStreams) > 0 {
+		toggleMultiViewStream(app, &app.TopStreams[s.SelectedStream])
+	}
+}
+
+func (s *MainScreen) handleKeyR1(app *app.App) {
+	if !s.Player.IsInForeground() {
+		startMultiView(app, s.Player)
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+)
+
+// toggleMultiViewStream picks the broadcaster of the stream for the multi-view started
+// with R1, or drops it when it was already picked. Offline channels cannot be picked.
+func toggleMultiViewStream(app *app.App, stream *model.Stream) {
+	broadcaster := stream.Broadcaster
+	if stream.Offline {
+		app.ShowToast(broadcaster.DisplayName + " is offline")
+		return
+	}
+
+	if index := multiViewIndex(app, broadcaster); index >= 0 {
+		app.MultiView = append(app.MultiView[:index], app.MultiView[index+1:]...)
+		app.ShowToast(broadcaster.DisplayName + " removed from the multi-view")
+		return
+	}
+
+	if len(app.MultiView) >= player.MaxMultiViewStreams {
+		app.ShowToast(fmt.Sprintf("A multi-view shows up to %d streams", player.MaxMultiViewStreams))
+		return
+	}
+
+	app.MultiView = append(app.MultiView, broadcaster)
+	app.ShowToast(fmt.Sprintf("%s added to the multi-view (%d/%d)", broadcaster.DisplayName, len(app.MultiView), player.MaxMultiViewStreams))
+}
+
+// multiViewIndex returns the position of the broadcaster among the streams picked for
+// the multi-view, -1 when it was not picked.
+func multiViewIndex(app *app.App, broadcaster *model.Broadcaster) int {
+	for i, picked := range app.MultiView {
+		if picked.Key() == broadcaster.Key() {
+			return i
+		}
+	}
+	return -1
+}
+
+// startMultiView plays the picked streams side by side, the picks are cleared once it
+// started.
+func startMultiView(app *app.App, mediaPlayer *player.Player) {
+	if len(app.MultiView) < 2 {
+		app.ShowToast("Pick 2 to 4 streams with L1 to start a multi-view")
+		return
+	}
+
+	broadcasters := app.MultiView
+	names := make([]string, len(broadcasters))
+	for i, broadcaster := range broadcasters {
+		names[i] = broadcaster.DisplayName
+	}
+
+	app.StopChat()
+	app.StartLoading(fmt.Sprintf("Loading multi-view of %d streams...", len(broadcasters)))
+	go func() {
+		if err := mediaPlayer.PlayMultiView(broadcasters); err != nil {
+			log.Printf("An error occurred while starting multi-view: %v", err)
+			app.FinishLoading()
+			app.ShowToast("Could not start multi-view: " + err.Error())
+			return
+		}
+
+		app.MultiView = nil
+		app.LoadingText = "Multi-view: " + strings.Join(names, ", ")
+	}()
+}
+
+// switchMultiViewAudio moves the audio of the multi-view to the previous or next stream.
+func switchMultiViewAudio(app *app.App, mediaPlayer *player.Player, direction int) {
+	go func() {
+		broadcaster, err := mediaPlayer.SwitchAudio(direction)
+		if err != nil {
+			log.Printf("An error occurred while switching multi-view audio: %v", err)
+			app.ShowToast("Could not switch audio: " + err.Error())
+			return
+		}
+		if broadcaster != nil {
+			showOsd(app, mediaPlayer, "Audio: "+broadcaster.DisplayName, -1)
+		}
+	}()
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/player"
+)
+
+const (
+	volumeStep    = 5
+	seekStep      = 30 * time.Second
+	osdLevelWidth = 20
+)
+
+// HandlePlaybackKey handles the keys that control the playback on any screen and
+// reports whether the key was used. While the player window is in front the D-pad
+// changes the volume, seeking videos with Left and Right, Select minimizes it, L1 mutes
+// and R1 pauses. During a multi-view L1 and R1 switch the stream heard instead. In the
+// background every key is left to the screens, which pick multi-view streams with L1
+// and R1.
+func HandlePlaybackKey(app *app.App, mediaPlayer *player.Player, key input.Key) bool {
+	if !mediaPlayer.IsPlaying() {
+		return false
+	}
+
+	foreground := mediaPlayer.IsInForeground()
+	multiView := mediaPlayer.IsMultiView()
+	switch {
+	case key == input.L1 && multiView:
+		switchMultiViewAudio(app, mediaPlayer, -1)
+	case key == input.R1 && multiView:
+		switchMultiViewAudio(app, mediaPlayer, 1)
+	case key == input.Select && foreground && !multiView:
+		minimizePlayer(app, mediaPlayer)
+	case key == input.Up && foreground:
+		changeVolume(app, mediaPlayer, volumeStep)
+	case key == input.Down && foreground:
+		changeVolume(app, mediaPlayer, -volumeStep)
+	case key == input.Left && foreground && isPlayingVideo(mediaPlayer):
+		seek(app, mediaPlayer, -seekStep)
+	case key == input.Right && foreground && isPlayingVideo(mediaPlayer):
+		seek(app, mediaPlayer, seekStep)
+	case key == input.L1 && foreground:
+		togglePlayback(app, mediaPlayer, "Muted", "Unmuted", mediaPlayer.ToggleMute, func(state *player.PlaybackState) bool { return state.Muted })
+	case key == input.R1 && foreground:
+		togglePlayback(app, mediaPlayer, "Paused", "Playing", mediaPlayer.TogglePause, func(state *player.PlaybackState) bool { return state.Paused })
+	default:
+		return false
+	}
+	return true
+}
+
+func changeVolume(app *app.App, mediaPlayer *player.Player, delta int) {
+	go func() {
+		volume, err := mediaPlayer.ChangeVolume(delta)
+		if err != nil {
+			showPlaybackError(app, err)
+			return
+		}
+		showOsd(app, mediaPlayer, fmt.Sprintf("Volume %d%%", volume), volume)
+	}()
+}
+
+func isPlayingVideo(mediaPlayer *player.Player) bool {
+	_, _, ok := mediaPlayer.VideoPosition()
+	return ok
+}
+
+// seek moves the video by delta and shows the new position against its length.
+func seek(app *app.App, mediaPlayer *player.Player, delta time.Duration) {
+	go func() {
+		if err := mediaPlayer.Seek(delta.Seconds()); err != nil {
+			showPlaybackError(app, err)
+			return
+		}
+
+		position, duration, ok := mediaPlayer.VideoPosition()
+		if !ok {
+			return
+		}
+		level := -1
+		if duration > 0 {
+			level = int(position * 100 / duration)
+		}
+		showOsd(app, mediaPlayer, formatDuration(position)+" / "+formatDuration(duration), level)
+	}()
+}
+
+// togglePlayback toggles a playback property through the backend and shows its new
+// state, on and off being read back from the player.
+func togglePlayback(app *app.App, mediaPlayer *player.Player, onText string, offText string, toggle func() error, isOn func(*player.PlaybackState) bool) {
+	go func() {
+		if err := toggle(); err != nil {
+			showPlaybackError(app, err)
+			return
+		}
+
+		state, err := mediaPlayer.State()
+		if err != nil || state == nil {
+			return
+		}
+
+		text := offText
+		if isOn(state) {
+			text = onText
+		}
+		showOsd(app, mediaPlayer, text, -1)
+	}()
+}
+
+// showOsd shows the text on top of the video through the player when it is in front
+// and has an OSD of its own, or in the app otherwise. A level between 0 and 100 is
+// drawn as a bar.
+func showOsd(app *app.App, mediaPlayer *player.Player, text string, level int) {
+	if !mediaPlayer.IsInForeground() {
+		app.ShowOsd(text, level)
+		return
+	}
+
+	playerText := text
+	if level >= 0 {
+		filled := level * osdLevelWidth / 100
+		playerText += "\n" + strings.Repeat("█", filled) + strings.Repeat("░", osdLevelWidth-filled)
+	}
+	err := mediaPlayer.ShowText(playerText)
+	if errors.Is(err, player.ErrUnsupported) {
+		app.ShowOsd(text, level)
+	} else if err != nil {
+		log.Printf("An error occurred while showing player OSD: %v", err)
+	}
+}
+
+func showPlaybackError(app *app.App, err error) {
+	if errors.Is(err, player.ErrUnsupported) {
+		app.ShowToast("Playback controls need the mpv player")
+		return
+	}
+	log.Printf("An error occurred while controlling playback: %v", err)
+}
+
+// minimizePlayer closes the player window and keeps listening to the stream in the
+// background, so browsing can go on.
+func minimizePlayer(app *app.App, mediaPlayer *player.Player) {
+	broadcaster := mediaPlayer.Broadcaster()
+	mediaPlayer.Stop()
+	app.StopChat()
+	app.FinishLoading()
+	app.RaiseAppWindow()
+
+	if broadcaster == nil {
+		return
+	}
+
+	app.StartLoading("Switching " + broadcaster.Login + " to audio only...")
+	go func() {
+		err := mediaPlayer.PlayAudioOnly(broadcaster)
+		app.FinishLoading()
+		if err != nil {
+			log.Printf("An error occurred while switching to audio only: %v", err)
+			app.ShowToast("Could not keep playing: " + err.Error())
+			return
+		}
+		app.ShowToast("Listening to " + broadcaster.DisplayName + " in the background")
+	}()
+}
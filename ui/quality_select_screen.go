@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/twitch"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+type QualitySelectScreen struct {
+	Previous     app.Screen
+	Broadcaster  *model.Broadcaster
+	Variants     []hls.Variant
+	SelectedItem int
+	FirstVisible int
+	Player       *player.Player
+}
+
+// OpenQualitySelectScreen fetches the available renditions of the broadcaster stream
+// and shows them on top of the previous screen.
+func OpenQualitySelectScreen(app *app.App, previous app.Screen, broadcaster *model.Broadcaster, mediaPlayer *player.Player) {
+	app.StartLoading("Loading " + broadcaster.Login + " stream qualities...")
+	go func() {
+		playlist, err := mediaPlayer.GetStreamPlaylist(broadcaster)
+		if err != nil {
+			log.Printf("An error occurred while fetching stream qualities for: %s, %v", broadcaster.Login, err)
+			app.ShowToast("Could not load " + broadcaster.Login + " stream qualities: " + err.Error())
+		} else {
+			app.State = CreateQualitySelectScreen(previous, broadcaster, playlist.Variants, mediaPlayer)
+		}
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func CreateQualitySelectScreen(previous app.Screen, broadcaster *model.Broadcaster, variants []hls.Variant, mediaPlayer *player.Player) *QualitySelectScreen {
+	return &QualitySelectScreen{
+		Previous:    previous,
+		Broadcaster: broadcaster,
+		Variants:    variants,
+		Player:      mediaPlayer,
+	}
+}
+
+func (s *QualitySelectScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp(appState)
+	case input.Down:
+		s.handleKeyDown(appState)
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	case input.X:
+		s.handleKeyX(appState)
+	case input.Y:
+		s.handleKeyY(appState)
+	case input.Select:
+		s.handleKeySelect(appState)
+	}
+}
+
+func (s *QualitySelectScreen) handleKeyUp(app *app.App) {
+	if s.SelectedItem <= 0 {
+		return
+	}
+
+	s.SelectedItem--
+	s.scrollToSelection(app.Config.UI.OverlayUiConfig.MaxVisibleItems)
+}
+
+func (s *QualitySelectScreen) handleKeyDown(app *app.App) {
+	if s.SelectedItem >= len(s.Variants) {
+		return
+	}
+
+	s.SelectedItem++
+	s.scrollToSelection(app.Config.UI.OverlayUiConfig.MaxVisibleItems)
+}
+
+func (s *QualitySelectScreen) handleKeyA(app *app.App) {
+	if len(s.Variants) == 0 {
+		return
+	}
+
+	selection, err := s.selection(app)
+	if err != nil {
+		log.Printf("An error occurred while selecting stream quality: %v", err)
+		return
+	}
+
+	s.play(app, selection)
+}
+
+// handleKeySelect listens to the audio only rendition in the background.
+func (s *QualitySelectScreen) handleKeySelect(app *app.App) {
+	for _, variant := range s.Variants {
+		if variant.IsAudioOnly {
+			s.play(app, &hls.Selection{Variant: variant})
+			return
+		}
+	}
+	app.ShowToast("No audio only rendition for " + s.Broadcaster.Login)
+}
+
+func (s *QualitySelectScreen) play(app *app.App, selection *hls.Selection) {
+	app.State = s.Previous
+	app.StopChat()
+	app.StartLoading("Loading " + s.Broadcaster.Login + " stream (" + selection.Variant.Quality() + ")...")
+
+	// Audio plays without a window, the app stays on screen with a now playing panel
+	if selection.Variant.IsAudioOnly {
+		go func() {
+			err := s.Player.PlayVariant(s.Broadcaster, selection.Variant)
+			app.FinishLoading()
+			if err != nil {
+				log.Printf("An error occurred while playing stream: %v", err)
+				return
+			}
+			app.ShowToast("Listening to " + s.Broadcaster.DisplayName)
+		}()
+		return
+	}
+
+	s.Player.ShowChat = app.UserDataManager.Data.ShowChat
+	go func() {
+		err := s.Player.PlayVariant(s.Broadcaster, selection.Variant)
+		if err != nil {
+			log.Printf("An error occurred while playing stream: %v", err)
+			app.FinishLoading()
+			return
+		}
+		app.LoadingText = selection.Description()
+		if s.Player.ShowChat && s.Broadcaster.Provider == twitch.ProviderName {
+			app.StartChat(s.Broadcaster.Login)
+		}
+	}()
+}
+
+// handleKeyY starts recording the selected quality, or stops the running recording
+// of the broadcaster.
+func (s *QualitySelectScreen) handleKeyY(app *app.App) {
+	if app.Recorder.IsRecording(s.Broadcaster) {
+		app.StartLoading("Stopping recording " + s.Broadcaster.Login + "...")
+		go func() {
+			app.Recorder.Stop(s.Broadcaster)
+			app.FinishLoading()
+			app.ShowToast("Recording of " + s.Broadcaster.Login + " saved")
+		}()
+		return
+	}
+
+	if len(s.Variants) == 0 {
+		return
+	}
+
+	selection, err := s.selection(app)
+	if err != nil {
+		log.Printf("An error occurred while selecting stream quality: %v", err)
+		return
+	}
+
+	_, err = app.Recorder.Start(s.Broadcaster, selection.Variant.Url)
+	if err != nil {
+		log.Printf("An error occurred while recording %s: %v", s.Broadcaster.Login, err)
+		app.ShowToast("Could not record: " + err.Error())
+		return
+	}
+	app.ShowToast("Recording " + s.Broadcaster.Login + " (" + selection.Variant.Quality() + ")")
+}
+
+// selection returns the highlighted variant, the first item being the automatic
+// selection based on the configured quality preferences.
+func (s *QualitySelectScreen) selection(app *app.App) (*hls.Selection, error) {
+	if s.SelectedItem == 0 {
+		return hls.SelectVariant(s.Variants, app.Config.Player.StreamQualityPreferences)
+	}
+	return &hls.Selection{Variant: s.Variants[s.SelectedItem-1]}, nil
+}
+
+func (s *QualitySelectScreen) handleKeyX(app *app.App) {
+	app.UserDataManager.Data.ShowChat = !app.UserDataManager.Data.ShowChat
+}
+
+func (s *QualitySelectScreen) handleKeyB(app *app.App) {
+	app.State = s.Previous
+	app.NeedsRedraw = true
+}
+
+func (s *QualitySelectScreen) scrollToSelection(maxVisibleItems int) {
+	if s.SelectedItem < s.FirstVisible {
+		s.FirstVisible = s.SelectedItem
+	}
+	if s.SelectedItem >= s.FirstVisible+maxVisibleItems {
+		s.FirstVisible = s.SelectedItem - maxVisibleItems + 1
+	}
+}
+
+func (s *QualitySelectScreen) Draw(app *app.App) {
+	s.Previous.Draw(app)
+
+	if app.IsLoading {
+		return
+	}
+
+	items := make([]string, len(s.Variants)+1)
+	items[0] = "Auto (" + strings.Join(app.Config.Player.StreamQualityPreferences, ", ") + ")"
+	for i := range s.Variants {
+		items[i+1] = s.Variants[i].Label()
+	}
+
+	hint := "X: Chat off"
+	if app.UserDataManager.Data.ShowChat {
+		hint = "X: Chat on"
+	}
+	if app.Recorder.IsRecording(s.Broadcaster) {
+		hint += " | Y: Stop recording"
+	} else {
+		hint += " | Y: Record"
+	}
+	hint += " | Select: Listen"
+
+	drawOverlayList(app, "Select quality", items, s.FirstVisible, s.SelectedItem, hint)
+}
+
+func drawOverlayList(app *app.App, title string, items []string, firstVisible int, selectedItem int, hint string) {
+	overlayConfig := app.Config.UI.OverlayUiConfig
+	visibleItems := len(items) - firstVisible
+	if visibleItems > overlayConfig.MaxVisibleItems {
+		visibleItems = overlayConfig.MaxVisibleItems
+	}
+
+	rows := visibleItems + 1
+	if hint != "" {
+		rows++
+	}
+
+	height := overlayConfig.RowHeight*int32(rows) + 3*overlayConfig.Padding
+	panel := sdl.Rect{
+		X: (app.Config.Display.Width - overlayConfig.Width) / 2,
+		Y: (app.Config.Display.Height - height) / 2,
+		W: overlayConfig.Width,
+		H: height,
+	}
+
+	app.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	app.FillRect(&panel, app.Config.UI.Colors.OverlayBackgroundColor)
+	app.DrawRect(&panel, app.Config.UI.Colors.OverlayBorderColor)
+
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	titleRect := sdl.Rect{X: panel.X, Y: panel.Y + overlayConfig.Padding, W: panel.W, H: overlayConfig.RowHeight}
+	app.DrawCenteredTextInRect(title, &titleRect, app.Config.UI.Colors.OverlayTitleColor)
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+
+	y := titleRect.Y + titleRect.H + overlayConfig.Padding
+	for i := firstVisible; i < firstVisible+visibleItems; i++ {
+		itemRect := sdl.Rect{
+			X: panel.X + overlayConfig.Padding,
+			Y: y,
+			W: panel.W - 2*overlayConfig.Padding,
+			H: overlayConfig.RowHeight,
+		}
+
+		color := app.Config.UI.Colors.OverlayItemTextColor
+		if i == selectedItem {
+			app.FillRect(&itemRect, app.Config.UI.Colors.SelectedOverlayItemColor)
+			color = app.Config.UI.Colors.SelectedOverlayItemTextColor
+		}
+		app.DrawCenteredTextInRect(items[i], &itemRect, color)
+
+		y += overlayConfig.RowHeight
+	}
+
+	if hint != "" {
+		hintRect := sdl.Rect{X: panel.X, Y: y, W: panel.W, H: overlayConfig.RowHeight}
+		app.DrawCenteredTextInRect(hint, &hintRect, app.Config.UI.Colors.OverlayItemTextColor)
+	}
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/recorder"
+)
+
+type RecordingsScreen struct {
+	Previous     app.Screen
+	Recordings   []recorder.RecordingFile
+	SelectedItem int
+	FirstVisible int
+	Player       *player.Player
+}
+
+// OpenRecordingsScreen lists the recordings in the background, probing the duration
+// of every file takes a moment.
+func OpenRecordingsScreen(app *app.App, previous app.Screen, mediaPlayer *player.Player) {
+	app.StartLoading("Loading recordings...")
+	go func() {
+		app.State = CreateRecordingsScreen(app, previous, mediaPlayer)
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func CreateRecordingsScreen(app *app.App, previous app.Screen, mediaPlayer *player.Player) *RecordingsScreen {
+	recordings, err := app.Recorder.ListRecordings()
+	if err != nil {
+		log.Printf("An error occurred while listing recordings in: %s, %v", app.Config.Recorder.Directory, err)
+		recordings = make([]recorder.RecordingFile, 0)
+	}
+
+	return &RecordingsScreen{
+		Previous:   previous,
+		Recordings: recordings,
+		Player:     mediaPlayer,
+	}
+}
+
+func (s *RecordingsScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp(appState)
+	case input.Down:
+		s.handleKeyDown(appState)
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	}
+}
+
+func (s *RecordingsScreen) handleKeyUp(app *app.App) {
+	if s.Player.IsInForeground() || s.SelectedItem <= 0 {
+		return
+	}
+
+	s.SelectedItem--
+	if s.SelectedItem < s.FirstVisible {
+		s.FirstVisible = s.SelectedItem
+	}
+}
+
+func (s *RecordingsScreen) handleKeyDown(app *app.App) {
+	if s.Player.IsInForeground() || s.SelectedItem >= len(s.Recordings)-1 {
+		return
+	}
+
+	s.SelectedItem++
+	if s.SelectedItem >= s.FirstVisible+app.Config.UI.OverlayUiConfig.MaxVisibleItems {
+		s.FirstVisible = s.SelectedItem - app.Config.UI.OverlayUiConfig.MaxVisibleItems + 1
+	}
+}
+
+// handleKeyA stops the selected recording when it is still running, or plays it.
+func (s *RecordingsScreen) handleKeyA(app *app.App) {
+	if s.Player.IsInForeground() || len(s.Recordings) == 0 {
+		return
+	}
+
+	recording := s.Recordings[s.SelectedItem]
+	if recording.Recording {
+		app.StartLoading("Stopping recording " + recording.Name + "...")
+		go func() {
+			app.Recorder.StopFile(recording.Path)
+			app.State = CreateRecordingsScreen(app, s.Previous, s.Player)
+			app.FinishLoading()
+			app.NeedsRedraw = true
+		}()
+		return
+	}
+
+	app.StartLoading("Playing " + recording.Name + "...")
+	go func() {
+		// Recordings play like videos, so they can be seeked through
+		video := &model.Video{Title: recording.Name, Duration: recording.Duration}
+		err := s.Player.PlayVideo(video, hls.Variant{Name: recording.Name, Url: recording.Path}, 0)
+		if err != nil {
+			log.Printf("An error occurred while playing recording: %v", err)
+			app.FinishLoading()
+		}
+	}()
+}
+
+func (s *RecordingsScreen) handleKeyB(app *app.App) {
+	if s.Player.IsInForeground() {
+		s.Player.Stop()
+		app.FinishLoading()
+		app.RaiseAppWindow()
+	} else {
+		app.State = s.Previous
+		app.NeedsRedraw = true
+	}
+}
+
+func (s *RecordingsScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	if len(s.Recordings) == 0 {
+		app.DrawCenteredText("No recordings in "+app.Config.Recorder.Directory, app.Config.UI.Colors.LoadingTextColor)
+		return
+	}
+
+	items := make([]string, len(s.Recordings))
+	for i, recording := range s.Recordings {
+		items[i] = recordingLabel(&recording)
+	}
+
+	drawOverlayList(app, "Recordings", items, s.FirstVisible, s.SelectedItem, "A: Play / Stop recording | B: Back")
+}
+
+func recordingLabel(recording *recorder.RecordingFile) string {
+	label := fmt.Sprintf("%s - %s - %s", recording.Name, formatSize(recording.Size), formatDuration(recording.Duration))
+	if recording.Recording {
+		label = "REC " + label
+	}
+	return label
+}
+
+func formatSize(bytes int64) string {
+	if bytes >= 1024*1024*1024 {
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1024*1024*1024))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
+
+func formatDuration(duration time.Duration) string {
+	seconds := int(duration.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -15,11 +15,14 @@ type SearchResultsScreen struct {
 	PageStartIndex int
 	PageEndIndex   int
 	Streams        []model.Stream
+	Query          string
+	IsLoadingMore  bool
 	Player         *player.Player
 }
 
-func CreateSearchResultsScreen(streams []model.Stream, mediaPlayer *player.Player) *SearchResultsScreen {
+func CreateSearchResultsScreen(query string, streams []model.Stream, mediaPlayer *player.Player) *SearchResultsScreen {
 	return &SearchResultsScreen{
+		Query:          query,
 		Streams:        streams,
 		PageStartIndex: 0,
 		PageEndIndex:   int(math.Min(float64(2), float64(len(streams)-1))),
@@ -33,6 +36,7 @@ func (s *SearchResultsScreen) HandleInput(appState *app.App, key input.Key) {
 		s.handleKeyUp()
 	case input.Down:
 		s.handleKeyDown()
+		s.loadMoreAtEnd(appState)
 	case input.A:
 		s.handleKeyA(appState)
 	case input.B:
@@ -43,6 +47,10 @@ func (s *SearchResultsScreen) HandleInput(appState *app.App, key input.Key) {
 		s.handleKeyY(appState)
 	case input.Left:
 		s.handleKeyLeft(appState)
+	case input.L1:
+		s.handleKeyL1(appState)
+	case input.R1:
+		s.handleKeyR1(appState)
 	}
 }
 
@@ -57,8 +65,9 @@ func (s *SearchResultsScreen) handleKeyX(appState *app.App) *SearchScreen {
 }
 
 func (s *SearchResultsScreen) handleKeyB(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() {
 		s.Player.Stop()
+		app.StopChat()
 		app.FinishLoading()
 		app.RaiseAppWindow()
 	} else {
@@ -67,22 +76,15 @@ func (s *SearchResultsScreen) handleKeyB(app *app.App) {
 }
 
 func (s *SearchResultsScreen) handleKeyA(app *app.App) {
-	if s.Player.IsPlaying() {
+	if s.Player.IsInForeground() || len(s.Streams) == 0 {
 		return
 	}
 
-	app.StartLoading("Loading " + s.Streams[s.SelectedStream].Broadcaster.Login + " stream...")
-	go func() {
-		err := s.Player.Play(s.Streams[s.SelectedStream].Broadcaster.Login)
-		if err != nil {
-			log.Printf("An error occurred while playing stream: %v", err)
-			app.FinishLoading()
-		}
-	}()
+	OpenStreamDetailsScreen(app, s, s.Streams[s.SelectedStream], s.Player)
 }
 
 func (s *SearchResultsScreen) handleKeyDown() {
-	if s.Player.IsPlaying() || s.SelectedStream >= len(s.Streams)-1 {
+	if s.Player.IsInForeground() || s.SelectedStream >= len(s.Streams)-1 {
 		return
 	}
 
@@ -93,8 +95,31 @@ func (s *SearchResultsScreen) handleKeyDown() {
 	}
 }
 
+// loadMoreAtEnd fetches the next page of results in the background once the last result is selected.
+func (s *SearchResultsScreen) loadMoreAtEnd(app *app.App) {
+	if s.IsLoadingMore || len(s.Streams) == 0 || s.SelectedStream != len(s.Streams)-1 {
+		return
+	}
+
+	cursor := s.Streams[len(s.Streams)-1].Cursor
+	if cursor == "" {
+		return
+	}
+
+	s.IsLoadingMore = true
+	go func() {
+		nextPage, err := app.Provider.SearchStreams(s.Query, cursor)
+		if err != nil {
+			log.Printf("An error occurred while fetching next page of streams for: %s, %v", s.Query, err)
+		} else {
+			s.Streams = model.AppendNewStreams(s.Streams, app.LoadStreamImages(nextPage))
+		}
+		s.IsLoadingMore = false
+	}()
+}
+
 func (s *SearchResultsScreen) handleKeyUp() {
-	if s.Player.IsPlaying() || s.SelectedStream <= 0 {
+	if s.Player.IsInForeground() || s.SelectedStream <= 0 {
 		return
 	}
 
@@ -119,8 +144,26 @@ func (s *SearchResultsScreen) Draw(app *app.App) {
 func (s *SearchResultsScreen) handleKeyLeft(app *app.App) {
 	app.StartLoading("Loading favorite streams...")
 	go func() {
-		app.State = CreateFavoriteBroadcastersScreen(app, s.Player)
+		favoritesScreen, err := CreateFavoriteBroadcastersScreen(app, s.Player)
+		if err != nil {
+			log.Printf("An error occurred while fetching favorite streams: %v", err)
+			app.ShowToast("Could not load favorite streams: " + err.Error())
+		} else {
+			app.State = favoritesScreen
+		}
 		app.FinishLoading()
 		app.NeedsRedraw = true
 	}()
 }
+
+func (s *SearchResultsScreen) handleKeyL1(app *app.App) {
+	if !s.Player.IsInForeground() && len(s.Streams) > 0 {
+		toggleMultiViewStream(app, &s.Streams[s.SelectedStream])
+	}
+}
+
+func (s *SearchResultsScreen) handleKeyR1(app *app.App) {
+	if !s.Player.IsInForeground() {
+		startMultiView(app, s.Player)
+	}
+}
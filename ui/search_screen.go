@@ -95,11 +95,11 @@ func (s *SearchScreen) handleKeyA(app *app.App) {
 	} else if keyValue == enter {
 		app.StartLoading("Searching streams...")
 		go func() {
-			streams, err := app.Config.TwitchService.SearchStreams(s.Input)
+			streams, err := app.Provider.SearchStreams(s.Input, "")
 			if err != nil {
 				log.Printf("An error occurred while fetching streams for: %s, %v", s.Input, err)
 			} else {
-				app.State = CreateSearchResultsScreen(streams, s.Player)
+				app.State = CreateSearchResultsScreen(s.Input, app.LoadStreamImages(streams), s.Player)
 			}
 			app.FinishLoading()
 			app.NeedsRedraw = true
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/fspasovski/pocketstream-app/app"
 	"github.com/fspasovski/pocketstream-app/model"
@@ -38,28 +39,13 @@ func drawStream(stream *model.Stream, app *app.App, x int32, y int32, selected b
 	}
 	app.FillRect(&thumbnailBg, app.Config.UI.Colors.StreamThumbnailBackgroundColor)
 
-	if len(stream.PreviewImageData) > 0 {
-		rw, err := sdl.RWFromMem(stream.PreviewImageData)
-		if err == nil {
-			surface, err := img.LoadRW(rw, true)
-			if err == nil {
-				defer surface.Free()
-
-				tex, err := app.CreateTextureFromSurface(surface)
-				if err == nil {
-					defer tex.Destroy()
-
-					previewDst := sdl.Rect{
-						X: x + app.Config.UI.StreamsUiConfig.Padding,
-						Y: y + app.Config.UI.StreamsUiConfig.Padding,
-						W: app.Config.UI.StreamsUiConfig.ThumbnailWidth - 2*app.Config.UI.StreamsUiConfig.Padding,
-						H: app.Config.UI.StreamsUiConfig.ThumbnailHeight - 2*app.Config.UI.StreamsUiConfig.Padding,
-					}
-					app.CopyTexture(tex, nil, &previewDst)
-				}
-			}
-		}
+	previewDst := sdl.Rect{
+		X: x + app.Config.UI.StreamsUiConfig.Padding,
+		Y: y + app.Config.UI.StreamsUiConfig.Padding,
+		W: app.Config.UI.StreamsUiConfig.ThumbnailWidth - 2*app.Config.UI.StreamsUiConfig.Padding,
+		H: app.Config.UI.StreamsUiConfig.ThumbnailHeight - 2*app.Config.UI.StreamsUiConfig.Padding,
 	}
+	drawImage(app, stream.PreviewImageData, &previewDst)
 
 	liveBadge := sdl.Rect{
 		X: x + app.Config.UI.StreamsUiConfig.LiveBadgeLeftMargin,
@@ -67,17 +53,32 @@ func drawStream(stream *model.Stream, app *app.App, x int32, y int32, selected b
 		W: app.Config.UI.StreamsUiConfig.LiveBadgeWidth,
 		H: app.Config.UI.StreamsUiConfig.LiveBadgeHeight,
 	}
-	app.FillRect(&liveBadge, app.Config.UI.Colors.StreamLiveBadgeBackgroundColor)
 
-	// Draw LIVE text
-	app.Font.SetStyle(ttf.STYLE_BOLD)
-	app.DrawCenteredTextInRect("LIVE", &liveBadge, app.Config.UI.Colors.LiveTextColor)
+	if stream.Offline {
+		drawOfflineBadge(app, stream, &thumbnailBg, &liveBadge)
+	} else {
+		app.FillRect(&liveBadge, app.Config.UI.Colors.StreamLiveBadgeBackgroundColor)
+
+		// Draw LIVE text
+		app.Font.SetStyle(ttf.STYLE_BOLD)
+		app.DrawCenteredTextInRect("LIVE", &liveBadge, app.Config.UI.Colors.LiveTextColor)
+	}
+
+	// Number the streams picked for the multi-view next to the LIVE badge
+	if index := multiViewIndex(app, stream.Broadcaster); index >= 0 {
+		multiViewBadge := liveBadge
+		multiViewBadge.X += liveBadge.W + app.Config.UI.StreamsUiConfig.Padding
+		app.FillRect(&multiViewBadge, app.Config.UI.Colors.ViewersCountBackgroundColor)
+		app.DrawCenteredTextInRect(fmt.Sprintf("#%d", index+1), &multiViewBadge, app.Config.UI.Colors.LiveTextColor)
+	}
 
-	// Draw viewer count badge (bottom right of thumbnail)
+	// Draw viewer count badge (bottom right of thumbnail), providers without viewer counts skip it
 	viewerText := fmt.Sprintf("%s", formatViewerCount(stream.ViewersCount))
 	app.Font.SetStyle(ttf.STYLE_NORMAL)
 	viewerSurface, err := app.Font.RenderUTF8Blended(viewerText, app.Config.UI.Colors.ViewersCountTextColor)
-	if err == nil {
+	if err == nil && stream.ViewersCount == 0 {
+		viewerSurface.Free()
+	} else if err == nil {
 		defer viewerSurface.Free()
 		viewerTexture, err := app.Renderer.CreateTextureFromSurface(viewerSurface)
 		if err == nil {
@@ -115,33 +116,26 @@ func drawStream(stream *model.Stream, app *app.App, x int32, y int32, selected b
 
 	//Draw profile picture if available
 	profileX := x + app.Config.UI.StreamsUiConfig.ProfileInfoLeftMargin
-	if len(stream.Broadcaster.ProfileImageData) > 0 {
-		rw, err := sdl.RWFromMem(stream.Broadcaster.ProfileImageData)
-		if err == nil {
-			surface, err := img.LoadRW(rw, true)
-			if err == nil {
-				defer surface.Free()
-
-				tex, err := app.CreateTextureFromSurface(surface)
-				if err == nil {
-					defer tex.Destroy()
-
-					profileDst := sdl.Rect{
-						X: profileX,
-						Y: y + 10,
-						W: app.Config.UI.StreamsUiConfig.ProfilePictureSize,
-						H: app.Config.UI.StreamsUiConfig.ProfilePictureSize,
-					}
-					app.CopyTexture(tex, nil, &profileDst)
-				}
-			}
-		}
+	profileDst := sdl.Rect{
+		X: profileX,
+		Y: y + 10,
+		W: app.Config.UI.StreamsUiConfig.ProfilePictureSize,
+		H: app.Config.UI.StreamsUiConfig.ProfilePictureSize,
 	}
+	drawImage(app, stream.Broadcaster.ProfileImageData, &profileDst)
 
 	// Draw streamer name (bold) - offset by profile picture width + spacing
 	nameX := profileX + app.Config.UI.StreamsUiConfig.ProfilePictureSize + app.Config.UI.StreamsUiConfig.ProfileNameLeftMargin
 	app.Font.SetStyle(ttf.STYLE_BOLD)
-	nameSurface, err := app.Font.RenderUTF8Blended(stream.Broadcaster.Login, app.Config.UI.Colors.StreamerNameTextColor)
+	nameColor := app.Config.UI.Colors.StreamerNameTextColor
+	if stream.Offline {
+		nameColor = app.Config.UI.Colors.StreamTitleColor
+	}
+	name := stream.Broadcaster.Login
+	if stream.Broadcaster.DisplayName != "" {
+		name = stream.Broadcaster.DisplayName
+	}
+	nameSurface, err := app.Font.RenderUTF8Blended(name, nameColor)
 	if err == nil {
 		defer nameSurface.Free()
 		nameTexture, err := app.CreateTextureFromSurface(nameSurface)
@@ -181,17 +175,130 @@ func drawStream(stream *model.Stream, app *app.App, x int32, y int32, selected b
 	}
 
 	if selected {
-		selectionRect := sdl.Rect{X: x, Y: y, W: app.Config.UI.StreamsUiConfig.Width, H: app.Config.UI.StreamsUiConfig.Height}
-
-		for i := int32(0); i < 3; i++ {
-			borderRect := sdl.Rect{X: selectionRect.X + i, Y: selectionRect.Y + i, W: selectionRect.W - 2*i, H: selectionRect.H - 2*i}
-			app.DrawRect(&borderRect, app.Config.UI.Colors.SelectedStreamBorderColor)
-		}
+		drawSelectionBorder(app, x, y)
 	}
 
 	return nil
 }
 
+// drawOfflineBadge greys out the thumbnail of an offline channel and labels it with
+// when the channel was last live in place of the LIVE badge.
+func drawOfflineBadge(app *app.App, stream *model.Stream, thumbnail *sdl.Rect, badge *sdl.Rect) {
+	app.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	app.FillRect(thumbnail, app.Config.UI.Colors.OfflineOverlayColor)
+
+	label := "Offline"
+	if !stream.LastLiveAt.IsZero() {
+		label = "Last live " + formatTimeAgo(time.Since(stream.LastLiveAt))
+	}
+
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	if width, _, err := app.Font.SizeUTF8(label); err == nil {
+		badge.W = max(badge.W, int32(width)+2*app.Config.UI.StreamsUiConfig.Padding)
+	}
+	app.FillRect(badge, app.Config.UI.Colors.ViewersCountBackgroundColor)
+	app.DrawCenteredTextInRect(label, badge, app.Config.UI.Colors.StreamTitleColor)
+}
+
+// formatTimeAgo formats how long ago something happened in its largest unit, e.g. "3h ago".
+func formatTimeAgo(elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+}
+
+func DrawCategories(app *app.App, categories []model.Category, startIndex int, endIndex int, selectedIndex int) {
+	app.ClearScreen()
+
+	if len(categories) == 0 {
+		app.DrawCenteredText("No results.", app.Config.UI.Colors.NoResultsTextColor)
+		return
+	}
+
+	y := app.Config.UI.HeaderHeight + app.Config.UI.StreamsTopMargin
+	for i := startIndex; i <= endIndex; i++ {
+		drawCategory(&categories[i], app, app.Config.UI.StreamLeftMargin, y, i == selectedIndex)
+		y += app.Config.UI.StreamsUiConfig.Height
+	}
+}
+
+func drawCategory(category *model.Category, app *app.App, x int32, y int32, selected bool) {
+	thumbnailBg := sdl.Rect{
+		X: x,
+		Y: y,
+		W: app.Config.UI.StreamsUiConfig.ThumbnailWidth,
+		H: app.Config.UI.StreamsUiConfig.ThumbnailHeight,
+	}
+	app.FillRect(&thumbnailBg, app.Config.UI.Colors.StreamThumbnailBackgroundColor)
+
+	// Box art is portrait, center it inside the landscape thumbnail area
+	boxArtHeight := app.Config.UI.StreamsUiConfig.ThumbnailHeight - 2*app.Config.UI.StreamsUiConfig.Padding
+	boxArtWidth := boxArtHeight * 3 / 4
+	boxArtDst := sdl.Rect{
+		X: x + (app.Config.UI.StreamsUiConfig.ThumbnailWidth-boxArtWidth)/2,
+		Y: y + app.Config.UI.StreamsUiConfig.Padding,
+		W: boxArtWidth,
+		H: boxArtHeight,
+	}
+	drawImage(app, category.BoxArtImageData, &boxArtDst)
+
+	name := category.DisplayName
+	if name == "" {
+		name = category.Name
+	}
+
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	app.DrawText(truncateText(name, app.Config.UI.StreamsUiConfig.MaxTitleLength), app.Config.UI.Colors.StreamerNameTextColor, x+app.Config.UI.StreamsUiConfig.ProfileInfoLeftMargin, y+app.Config.UI.StreamsUiConfig.ProfileInfoTopMargin)
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+	countText := formatViewerCount(category.ViewersCount) + " viewers"
+	if category.ChannelsCount > 0 {
+		countText = fmt.Sprintf("%d channels", category.ChannelsCount)
+	}
+	app.DrawText(countText, app.Config.UI.Colors.StreamTitleColor, app.Config.UI.StreamsUiConfig.TitleLeftMargin, y+app.Config.UI.StreamsUiConfig.TitleTopMargin)
+
+	if selected {
+		drawSelectionBorder(app, x, y)
+	}
+}
+
+func drawImage(app *app.App, data []byte, dst *sdl.Rect) {
+	if len(data) == 0 {
+		return
+	}
+
+	rw, err := sdl.RWFromMem(data)
+	if err != nil {
+		return
+	}
+
+	surface, err := img.LoadRW(rw, true)
+	if err != nil {
+		return
+	}
+	defer surface.Free()
+
+	tex, err := app.CreateTextureFromSurface(surface)
+	if err != nil {
+		return
+	}
+	defer tex.Destroy()
+
+	app.CopyTexture(tex, nil, dst)
+}
+
+func drawSelectionBorder(app *app.App, x int32, y int32) {
+	selectionRect := sdl.Rect{X: x, Y: y, W: app.Config.UI.StreamsUiConfig.Width, H: app.Config.UI.StreamsUiConfig.Height}
+
+	for i := int32(0); i < 3; i++ {
+		borderRect := sdl.Rect{X: selectionRect.X + i, Y: selectionRect.Y + i, W: selectionRect.W - 2*i, H: selectionRect.H - 2*i}
+		app.DrawRect(&borderRect, app.Config.UI.Colors.SelectedStreamBorderColor)
+	}
+}
+
 func formatViewerCount(count int) string {
 	if count >= 1000 {
 		return fmt.Sprintf("%.1fK", float64(count)/1000.0)
@@ -0,0 +1,392 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
+	"github.com/fspasovski/pocketstream-app/twitch"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+type streamDetailsAction int
+
+const (
+	actionPlay streamDetailsAction = iota
+	actionAudioOnly
+	actionFavorite
+	actionRecord
+	actionChat
+	actionVideos
+	actionClips
+)
+
+type StreamDetailsScreen struct {
+	Previous         app.Screen
+	Stream           model.Stream
+	Details          *model.StreamDetails
+	PreviewImageData []byte
+	SelectedAction   int
+	// ChatOpen is set when the chat was opened from this screen, it is closed on leaving
+	ChatOpen bool
+	Player   *player.Player
+}
+
+// OpenStreamDetailsScreen fetches what the provider knows about the stream beyond the
+// list item and shows it with the actions available for the stream. Providers without
+// details show the list item as it is.
+func OpenStreamDetailsScreen(app *app.App, previous app.Screen, stream model.Stream, mediaPlayer *player.Player) {
+	app.StartLoading("Loading " + stream.Broadcaster.Login + " stream details...")
+	go func() {
+		details := &model.StreamDetails{Stream: &stream}
+		streamProvider, _ := app.Config.Provider(stream.Broadcaster.Provider)
+		if detailsProvider, supported := streamProvider.(provider.StreamDetailsProvider); supported && !stream.Offline {
+			streamDetails, err := detailsProvider.GetStreamDetails(stream.Broadcaster.Login)
+			if err != nil {
+				log.Printf("An error occurred while fetching stream details for: %s, %v", stream.Broadcaster.Key(), err)
+			} else {
+				details = streamDetails
+			}
+		}
+
+		previewImageData := stream.PreviewImageData
+		if previewImageUrl := details.Stream.PreviewImageURL; previewImageUrl != "" && previewImageUrl != stream.PreviewImageURL {
+			if imageData := app.ImageDataService.GetImageData([]string{previewImageUrl})[previewImageUrl]; imageData != nil {
+				previewImageData = imageData
+			}
+		}
+
+		app.State = CreateStreamDetailsScreen(previous, stream, details, previewImageData, mediaPlayer)
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func CreateStreamDetailsScreen(previous app.Screen, stream model.Stream, details *model.StreamDetails, previewImageData []byte, mediaPlayer *player.Player) *StreamDetailsScreen {
+	return &StreamDetailsScreen{
+		Previous:         previous,
+		Stream:           stream,
+		Details:          details,
+		PreviewImageData: previewImageData,
+		Player:           mediaPlayer,
+	}
+}
+
+func (s *StreamDetailsScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Left:
+		s.handleKeyLeft()
+	case input.Right:
+		s.handleKeyRight()
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	}
+}
+
+func (s *StreamDetailsScreen) handleKeyLeft() {
+	if s.Player.IsInForeground() || s.SelectedAction <= 0 {
+		return
+	}
+	s.SelectedAction--
+}
+
+func (s *StreamDetailsScreen) handleKeyRight() {
+	if s.Player.IsInForeground() || s.SelectedAction >= len(s.actions())-1 {
+		return
+	}
+	s.SelectedAction++
+}
+
+func (s *StreamDetailsScreen) handleKeyA(app *app.App) {
+	if s.Player.IsInForeground() {
+		return
+	}
+
+	switch s.actions()[s.SelectedAction] {
+	case actionPlay:
+		s.closeChat(app)
+		OpenQualitySelectScreen(app, s, s.Stream.Broadcaster, s.Player)
+	case actionAudioOnly:
+		s.listen(app)
+	case actionFavorite:
+		app.UserDataManager.ToggleFavoriteBroadcaster(s.Stream.Broadcaster)
+	case actionRecord:
+		s.toggleRecording(app)
+	case actionChat:
+		s.toggleChat(app)
+	case actionVideos:
+		s.closeChat(app)
+		videoProvider, _ := s.videoProvider()
+		OpenVideosScreen(app, s, s.Stream.Broadcaster, videoProvider, s.Player)
+	case actionClips:
+		s.closeChat(app)
+		clipProvider, _ := s.clipProvider()
+		OpenBroadcasterClipsScreen(app, s, s.Stream.Broadcaster, clipProvider, s.Player)
+	}
+}
+
+func (s *StreamDetailsScreen) handleKeyB(app *app.App) {
+	if s.Player.IsInForeground() {
+		s.Player.Stop()
+		app.StopChat()
+		app.FinishLoading()
+		app.RaiseAppWindow()
+		return
+	}
+
+	s.closeChat(app)
+	app.State = s.Previous
+	app.NeedsRedraw = true
+}
+
+// actions returns the actions available for the stream, the chat only exists on Twitch
+// and the videos and clips only with providers that keep them. Offline channels can
+// only be favorited and browsed, the ones of providers no longer configured only
+// unfavorited.
+func (s *StreamDetailsScreen) actions() []streamDetailsAction {
+	actions := []streamDetailsAction{actionFavorite}
+	if !s.Stream.Offline && s.providerConfigured() {
+		actions = []streamDetailsAction{actionPlay, actionAudioOnly, actionFavorite, actionRecord}
+		if s.Stream.Broadcaster.Provider == twitch.ProviderName {
+			actions = append(actions, actionChat)
+		}
+	}
+	if _, supported := s.videoProvider(); supported {
+		actions = append(actions, actionVideos)
+	}
+	if _, supported := s.clipProvider(); supported {
+		actions = append(actions, actionClips)
+	}
+	return actions
+}
+
+func (s *StreamDetailsScreen) providerConfigured() bool {
+	_, configured := s.Player.Cfg.Provider(s.Stream.Broadcaster.Provider)
+	return configured
+}
+
+func (s *StreamDetailsScreen) videoProvider() (provider.VideoProvider, bool) {
+	streamProvider, _ := s.Player.Cfg.Provider(s.Stream.Broadcaster.Provider)
+	videoProvider, supported := streamProvider.(provider.VideoProvider)
+	return videoProvider, supported
+}
+
+func (s *StreamDetailsScreen) clipProvider() (provider.ClipProvider, bool) {
+	streamProvider, _ := s.Player.Cfg.Provider(s.Stream.Broadcaster.Provider)
+	clipProvider, supported := streamProvider.(provider.ClipProvider)
+	return clipProvider, supported
+}
+
+func (s *StreamDetailsScreen) actionLabel(app *app.App, action streamDetailsAction) string {
+	switch action {
+	case actionPlay:
+		return "Play"
+	case actionAudioOnly:
+		return "Audio only"
+	case actionFavorite:
+		if app.UserDataManager.IsFavoriteBroadcaster(s.Stream.Broadcaster) {
+			return "Unfavorite"
+		}
+		return "Favorite"
+	case actionRecord:
+		if app.Recorder.IsRecording(s.Stream.Broadcaster) {
+			return "Stop recording"
+		}
+		return "Record"
+	case actionChat:
+		if s.ChatOpen {
+			return "Close chat"
+		}
+		return "Open chat"
+	case actionVideos:
+		return "Videos"
+	case actionClips:
+		return "Clips"
+	}
+	return ""
+}
+
+// listen plays the audio only rendition in the background and stays on the details.
+func (s *StreamDetailsScreen) listen(app *app.App) {
+	broadcaster := s.Stream.Broadcaster
+	app.StartLoading("Loading " + broadcaster.Login + " audio...")
+	go func() {
+		err := s.Player.PlayAudioOnly(broadcaster)
+		app.FinishLoading()
+		if err != nil {
+			log.Printf("An error occurred while playing audio only: %v", err)
+			app.ShowToast("Could not play audio: " + err.Error())
+			return
+		}
+		app.ShowToast("Listening to " + broadcaster.DisplayName)
+	}()
+}
+
+// toggleRecording records the stream in the preferred quality, or stops its recording.
+func (s *StreamDetailsScreen) toggleRecording(app *app.App) {
+	broadcaster := s.Stream.Broadcaster
+	if app.Recorder.IsRecording(broadcaster) {
+		app.StartLoading("Stopping recording " + broadcaster.Login + "...")
+		go func() {
+			app.Recorder.Stop(broadcaster)
+			app.FinishLoading()
+			app.ShowToast("Recording of " + broadcaster.Login + " saved")
+		}()
+		return
+	}
+
+	app.StartLoading("Starting recording " + broadcaster.Login + "...")
+	go func() {
+		defer app.FinishLoading()
+
+		selection, err := s.Player.GetStreamingUrl(broadcaster, app.Config.Player.StreamQualityPreferences)
+		if err == nil {
+			_, err = app.Recorder.Start(broadcaster, selection.Variant.Url)
+		}
+		if err != nil {
+			log.Printf("An error occurred while recording %s: %v", broadcaster.Login, err)
+			app.ShowToast("Could not record: " + err.Error())
+			return
+		}
+		app.ShowToast("Recording " + broadcaster.Login + " (" + selection.Variant.Quality() + ")")
+	}()
+}
+
+func (s *StreamDetailsScreen) toggleChat(app *app.App) {
+	if s.ChatOpen {
+		s.closeChat(app)
+		return
+	}
+
+	app.StartLoading("Joining " + s.Stream.Broadcaster.Login + " chat...")
+	go func() {
+		app.StartChat(s.Stream.Broadcaster.Login)
+		s.ChatOpen = app.Chat != nil
+		app.FinishLoading()
+		if !s.ChatOpen {
+			app.ShowToast("Could not join the chat")
+		}
+	}()
+}
+
+func (s *StreamDetailsScreen) closeChat(app *app.App) {
+	if s.ChatOpen {
+		app.StopChat()
+		s.ChatOpen = false
+	}
+}
+
+func (s *StreamDetailsScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	margin := app.Config.UI.StreamLeftMargin
+	top := app.Config.UI.HeaderHeight + app.Config.UI.StreamsTopMargin
+	lineHeight := int32(app.Font.Height())
+	columnWidth := app.Config.Display.Width/2 - margin
+
+	preview := sdl.Rect{X: margin, Y: top, W: columnWidth - margin, H: (columnWidth - margin) * 9 / 16}
+	app.FillRect(&preview, app.Config.UI.Colors.StreamThumbnailBackgroundColor)
+	drawImage(app, s.PreviewImageData, &preview)
+
+	// The full title goes under the preview, wrapped over as many lines as it needs
+	y := preview.Y + preview.H + lineHeight/2
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	for _, line := range app.WrapText(app.Font, s.Details.Stream.Title, app.Config.Display.Width-2*margin) {
+		app.DrawText(line, app.Config.UI.Colors.StreamerNameTextColor, margin, y)
+		y += lineHeight
+	}
+
+	x := app.Config.Display.Width/2 + margin
+	y = top
+	app.DrawText(s.Stream.Broadcaster.DisplayName, app.Config.UI.Colors.StreamerNameTextColor, x, y)
+	y += lineHeight
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+	for _, line := range s.infoLines() {
+		for _, wrapped := range app.WrapText(app.Font, line, columnWidth-margin) {
+			app.DrawText(wrapped, app.Config.UI.Colors.StreamTitleColor, x, y)
+			y += lineHeight
+		}
+	}
+
+	s.drawActions(app)
+	app.DrawChatPanel()
+}
+
+// infoLines returns the known details of the stream, one line each.
+func (s *StreamDetailsScreen) infoLines() []string {
+	lines := make([]string, 0)
+	if s.Details.Category != "" {
+		lines = append(lines, "Playing "+s.Details.Category)
+	}
+	if s.Details.Stream.ViewersCount > 0 {
+		lines = append(lines, formatViewerCount(s.Details.Stream.ViewersCount)+" viewers")
+	}
+	if !s.providerConfigured() {
+		lines = append(lines, "Provider "+s.Stream.Broadcaster.Provider+" not configured")
+	} else if s.Stream.Offline {
+		lines = append(lines, "Offline")
+		if !s.Stream.LastLiveAt.IsZero() {
+			lines = append(lines, "Last live "+formatTimeAgo(time.Since(s.Stream.LastLiveAt)))
+		}
+	}
+	if !s.Details.StartedAt.IsZero() {
+		lines = append(lines, "Live for "+formatUptime(time.Since(s.Details.StartedAt)))
+	}
+	if s.Details.FollowersCount > 0 {
+		lines = append(lines, formatViewerCount(s.Details.FollowersCount)+" followers")
+	}
+	if s.Details.Language != "" {
+		lines = append(lines, "Language: "+s.Details.Language)
+	}
+	if len(s.Details.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(s.Details.Tags, ", "))
+	}
+	return lines
+}
+
+// drawActions draws the actions as a row of buttons above the footer.
+func (s *StreamDetailsScreen) drawActions(app *app.App) {
+	actions := s.actions()
+	margin := app.Config.UI.StreamLeftMargin
+	rowHeight := app.Config.UI.OverlayUiConfig.RowHeight
+	gap := app.Config.UI.StreamsUiConfig.Padding
+	width := (app.Config.Display.Width-2*margin)/int32(len(actions)) - gap
+	y := app.Config.Display.Height - app.Config.UI.FooterHeight - rowHeight - margin
+
+	for i, action := range actions {
+		button := sdl.Rect{X: margin + int32(i)*(width+gap), Y: y, W: width, H: rowHeight}
+		color := app.Config.UI.Colors.KeyColor
+		if i == s.SelectedAction {
+			app.FillRect(&button, app.Config.UI.Colors.SelectedKeyBackgroundColor)
+			app.DrawRect(&button, app.Config.UI.Colors.SelectedKeyBorderColor)
+			color = app.Config.UI.Colors.SelectedKeyColor
+		} else {
+			app.FillRect(&button, app.Config.UI.Colors.KeyBackgroundColor)
+			app.DrawRect(&button, app.Config.UI.Colors.KeyBorderColor)
+		}
+		app.DrawCenteredTextInRect(s.actionLabel(app, action), &button, color)
+	}
+}
+
+// formatUptime formats a duration as hours and minutes, e.g. "2h 05m".
+func formatUptime(uptime time.Duration) string {
+	minutes := int(uptime.Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	"github.com/fspasovski/pocketstream-app/app"
+	"github.com/fspasovski/pocketstream-app/hls"
+	"github.com/fspasovski/pocketstream-app/input"
+	"github.com/fspasovski/pocketstream-app/model"
+	"github.com/fspasovski/pocketstream-app/player"
+	"github.com/fspasovski/pocketstream-app/provider"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+type VideosScreen struct {
+	Previous       app.Screen
+	Broadcaster    *model.Broadcaster
+	VideoProvider  provider.VideoProvider
+	Videos         []model.Video
+	SelectedVideo  int
+	PageStartIndex int
+	PageEndIndex   int
+	Player         *player.Player
+}
+
+// OpenVideosScreen lists the past broadcasts and highlights of the broadcaster, loading
+// them and their thumbnails in the background.
+func OpenVideosScreen(app *app.App, previous app.Screen, broadcaster *model.Broadcaster, videoProvider provider.VideoProvider, mediaPlayer *player.Player) {
+	app.StartLoading("Loading " + broadcaster.Login + " videos...")
+	go func() {
+		videos, err := videoProvider.GetVideos(broadcaster.Login)
+		if err != nil {
+			log.Printf("An error occurred while fetching videos for: %s, %v", broadcaster.Key(), err)
+			videos = make([]model.Video, 0)
+		}
+
+		app.State = CreateVideosScreen(previous, broadcaster, videoProvider, loadVideoImages(app, videos), mediaPlayer)
+		app.FinishLoading()
+		app.NeedsRedraw = true
+	}()
+}
+
+func CreateVideosScreen(previous app.Screen, broadcaster *model.Broadcaster, videoProvider provider.VideoProvider, videos []model.Video, mediaPlayer *player.Player) *VideosScreen {
+	return &VideosScreen{
+		Previous:       previous,
+		Broadcaster:    broadcaster,
+		VideoProvider:  videoProvider,
+		Videos:         videos,
+		PageStartIndex: 0,
+		PageEndIndex:   min(2, len(videos)-1),
+		Player:         mediaPlayer,
+	}
+}
+
+// loadVideoImages fills in the thumbnails of the videos.
+func loadVideoImages(app *app.App, videos []model.Video) []model.Video {
+	imageUrls := make([]string, 0)
+	for _, video := range videos {
+		if video.PreviewImageData == nil && video.PreviewImageURL != "" {
+			imageUrls = append(imageUrls, video.PreviewImageURL)
+		}
+	}
+
+	if len(imageUrls) == 0 {
+		return videos
+	}
+
+	imageData := app.ImageDataService.GetImageData(imageUrls)
+	for i := range videos {
+		if videos[i].PreviewImageData == nil {
+			videos[i].PreviewImageData = imageData[videos[i].PreviewImageURL]
+		}
+	}
+	return videos
+}
+
+func (s *VideosScreen) HandleInput(appState *app.App, key input.Key) {
+	switch key {
+	case input.Up:
+		s.handleKeyUp()
+	case input.Down:
+		s.handleKeyDown()
+	case input.A:
+		s.handleKeyA(appState)
+	case input.B:
+		s.handleKeyB(appState)
+	}
+}
+
+func (s *VideosScreen) handleKeyDown() {
+	if s.Player.IsInForeground() || s.SelectedVideo >= len(s.Videos)-1 {
+		return
+	}
+
+	s.SelectedVideo++
+	if s.SelectedVideo > s.PageEndIndex {
+		s.PageStartIndex++
+		s.PageEndIndex = min(s.PageEndIndex+1, len(s.Videos)-1)
+	}
+}
+
+func (s *VideosScreen) handleKeyUp() {
+	if s.Player.IsInForeground() || s.SelectedVideo <= 0 {
+		return
+	}
+
+	s.SelectedVideo--
+	if s.SelectedVideo < s.PageStartIndex {
+		s.PageStartIndex--
+		s.PageEndIndex--
+	}
+}
+
+// handleKeyA plays the selected video from its start in the preferred quality.
+func (s *VideosScreen) handleKeyA(app *app.App) {
+	if s.Player.IsInForeground() || len(s.Videos) == 0 {
+		return
+	}
+
+	video := &s.Videos[s.SelectedVideo]
+	app.StopChat()
+	app.StartLoading("Loading " + truncateText(video.Title, app.Config.UI.StreamsUiConfig.MaxTitleLength) + "...")
+	go func() {
+		selection, err := s.getVideoUrl(app, video)
+		if err == nil {
+			// Past broadcasts have no live chat to show next to them
+			s.Player.ShowChat = false
+			err = s.Player.PlayVideo(video, selection.Variant, 0)
+		}
+		if err != nil {
+			log.Printf("An error occurred while playing video: %s, %v", video.Id, err)
+			app.FinishLoading()
+			app.ShowToast("Could not play video: " + err.Error())
+			return
+		}
+		app.LoadingText = "Playing " + video.Title + " (" + selection.Variant.Quality() + ") | Left/Right: Seek"
+	}()
+}
+
+func (s *VideosScreen) getVideoUrl(app *app.App, video *model.Video) (*hls.Selection, error) {
+	playlist, err := s.VideoProvider.GetVideoPlaylist(video.Id)
+	if err != nil {
+		return nil, err
+	}
+	return hls.SelectVariant(playlist.Variants, app.Config.Player.StreamQualityPreferences)
+}
+
+func (s *VideosScreen) handleKeyB(app *app.App) {
+	if s.Player.IsInForeground() {
+		s.Player.Stop()
+		app.FinishLoading()
+		app.RaiseAppWindow()
+		return
+	}
+
+	app.State = s.Previous
+	app.NeedsRedraw = true
+}
+
+func (s *VideosScreen) Draw(app *app.App) {
+	app.ClearScreen()
+
+	if app.IsLoading {
+		app.DrawLoadingScreen()
+		return
+	}
+
+	if len(s.Videos) == 0 {
+		app.DrawCenteredText(s.Broadcaster.DisplayName+" has no videos.", app.Config.UI.Colors.NoResultsTextColor)
+		return
+	}
+
+	y := app.Config.UI.HeaderHeight + app.Config.UI.StreamsTopMargin
+	for i := s.PageStartIndex; i <= s.PageEndIndex; i++ {
+		drawVideo(&s.Videos[i], app, app.Config.UI.StreamLeftMargin, y, i == s.SelectedVideo)
+		y += app.Config.UI.StreamsUiConfig.Height
+	}
+}
+
+// drawVideo draws a video like a stream, with its type in place of the LIVE badge and
+// its duration in place of the viewer count.
+func drawVideo(video *model.Video, app *app.App, x int32, y int32, selected bool) {
+	cfg := app.Config.UI.StreamsUiConfig
+
+	thumbnailBg := sdl.Rect{X: x, Y: y, W: cfg.ThumbnailWidth, H: cfg.ThumbnailHeight}
+	app.FillRect(&thumbnailBg, app.Config.UI.Colors.StreamThumbnailBackgroundColor)
+
+	previewDst := sdl.Rect{
+		X: x + cfg.Padding,
+		Y: y + cfg.Padding,
+		W: cfg.ThumbnailWidth - 2*cfg.Padding,
+		H: cfg.ThumbnailHeight - 2*cfg.Padding,
+	}
+	drawImage(app, video.PreviewImageData, &previewDst)
+
+	// The badge grows with labels longer than LIVE
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	label := videoTypeLabel(video)
+	typeBadge := sdl.Rect{X: x + cfg.LiveBadgeLeftMargin, Y: y + cfg.LiveBadgeTopMargin, W: cfg.LiveBadgeWidth, H: cfg.LiveBadgeHeight}
+	if width, _, err := app.Font.SizeUTF8(label); err == nil {
+		typeBadge.W = max(typeBadge.W, int32(width)+2*cfg.Padding)
+	}
+	app.Renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	app.FillRect(&typeBadge, app.Config.UI.Colors.ViewersCountBackgroundColor)
+	app.DrawCenteredTextInRect(label, &typeBadge, app.Config.UI.Colors.LiveTextColor)
+
+	// Duration at the bottom right of the thumbnail
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+	duration := formatDuration(video.Duration)
+	if width, height, err := app.Font.SizeUTF8(duration); err == nil {
+		durationBg := sdl.Rect{
+			X: x + cfg.ThumbnailWidth - int32(width) - 15,
+			Y: y + cfg.ThumbnailHeight - int32(height) - 10,
+			W: int32(width) + 10,
+			H: int32(height) + 4,
+		}
+		app.FillRect(&durationBg, app.Config.UI.Colors.ViewersCountBackgroundColor)
+		app.DrawText(duration, app.Config.UI.Colors.ViewersCountTextColor, durationBg.X+5, durationBg.Y+2)
+	}
+
+	app.Font.SetStyle(ttf.STYLE_BOLD)
+	app.DrawText(truncateText(video.Title, cfg.MaxTitleLength), app.Config.UI.Colors.StreamerNameTextColor, x+cfg.ProfileInfoLeftMargin, y+10)
+
+	app.Font.SetStyle(ttf.STYLE_NORMAL)
+	info := make([]string, 0)
+	// Clips of a category come from many channels
+	if video.Type == "clip" && video.Broadcaster != nil {
+		info = append(info, video.Broadcaster.DisplayName)
+	}
+	if !video.PublishedAt.IsZero() {
+		info = append(info, video.PublishedAt.Local().Format("Jan 2, 2006"))
+	}
+	if video.ViewsCount > 0 {
+		info = append(info, formatViewerCount(video.ViewsCount)+" views")
+	}
+	app.DrawText(strings.Join(info, " - "), app.Config.UI.Colors.StreamTitleColor, cfg.TitleLeftMargin, y+cfg.TitleTopMargin)
+
+	if selected {
+		drawSelectionBorder(app, x, y)
+	}
+}
+
+func videoTypeLabel(video *model.Video) string {
+	switch video.Type {
+	case "highlight":
+		return "HIGHLIGHT"
+	case "clip":
+		return "CLIP"
+	}
+	return "VOD"
+}
@@ -0,0 +1,78 @@
+package video
+
+import "sync"
+
+// Frame is a decoded YUV 4:2:0 picture and where it goes in the app window. Frames that
+// are not Positioned are centered.
+type Frame struct {
+	Data       []byte
+	X          int
+	Y          int
+	Width      int
+	Height     int
+	Positioned bool
+}
+
+// FrameBuffer hands the latest decoded frame from the decoder over to the renderer,
+// frames the renderer did not get to in time are dropped.
+type FrameBuffer struct {
+	mu       sync.Mutex
+	frame    Frame
+	active   bool
+	sequence uint64
+}
+
+// FrameSize returns the number of bytes of a YUV 4:2:0 frame of the given size.
+func FrameSize(width int, height int) int {
+	return width * height * 3 / 2
+}
+
+// Start prepares the buffer for frames of the given size and position.
+func (b *FrameBuffer) Start(x int, y int, width int, height int, positioned bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.frame = Frame{X: x, Y: y, Width: width, Height: height, Positioned: positioned}
+	b.active = true
+}
+
+// Put replaces the latest frame with a copy of data, frames of another size than the
+// buffer was started with are left over from a previous stream and dropped.
+func (b *FrameBuffer) Put(data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.active || len(data) != FrameSize(b.frame.Width, b.frame.Height) {
+		return
+	}
+	if b.frame.Data == nil {
+		b.frame.Data = make([]byte, len(data))
+	}
+	copy(b.frame.Data, data)
+	b.sequence++
+}
+
+// Stop drops the latest frame, nothing is drawn until the buffer is started again.
+func (b *FrameBuffer) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.frame = Frame{}
+	b.active = false
+}
+
+// Update calls update with the latest frame when it is newer than the given sequence
+// and returns the sequence of the latest frame, 0 when there is none. The frame data is
+// only valid during the call.
+func (b *FrameBuffer) Update(sequence uint64, update func(frame *Frame)) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.active || b.frame.Data == nil {
+		return 0
+	}
+	if b.sequence != sequence {
+		update(&b.frame)
+	}
+	return b.sequence
+}